@@ -0,0 +1,72 @@
+// Команда physreplay воспроизводит журнал физики без окна и сравнивает его с повторной симуляцией.
+//
+//	physreplay -trace traces/1/trace.bin -world traces/1
+//
+// Журнал записывается методом physics.PhysicsEngine.StartTrace (в игре - клавишей F9), мир -
+// методом world.World.Save. Команда сообщает первый тик, на котором повторная симуляция
+// разошлась с записью, и завершается с кодом 1.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/user/gengine/physics"
+	"github.com/user/gengine/world"
+)
+
+func main() {
+	tracePath := flag.String("trace", "", "файл журнала физики")
+	worldDir := flag.String("world", "", "каталог с чанками мира (без него тела движутся без коллизий)")
+	epsilon := flag.Float64("epsilon", 0, "допустимое расхождение координат, скоростей и сил")
+	verbose := flag.Bool("v", false, "выводить записанное состояние тел на каждом тике")
+	flag.Parse()
+
+	if *tracePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(*tracePath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия журнала: %v", err)
+	}
+	trace, err := physics.ReadTrace(file)
+	file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var collisionWorld physics.CollisionWorld
+	if *worldDir != "" {
+		w, err := world.LoadWorld(*worldDir)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки мира: %v", err)
+		}
+		collisionWorld = w
+	}
+
+	fmt.Printf("Тел: %d, контроллеров: %d, датчиков: %d, тиков: %d\n",
+		len(trace.Bodies), len(trace.Controllers), len(trace.Sensors), len(trace.Ticks))
+
+	if *verbose {
+		for _, tick := range trace.Ticks {
+			for i, body := range tick.Bodies {
+				fmt.Printf("тик %d, тело %d: позиция %v, скорость %v, сила %v, на земле %v, контакты %06b\n",
+					tick.Tick, i, body.Position, body.Velocity, body.Force, body.Grounded, body.Contacts)
+			}
+		}
+	}
+
+	divergence, err := trace.Replay(collisionWorld, float32(*epsilon))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if divergence != nil {
+		fmt.Println("Расхождение:", divergence)
+		os.Exit(1)
+	}
+	fmt.Println("Повторная симуляция совпадает с журналом")
+}
@@ -27,14 +27,16 @@ func main() {
 	defer gameInstance.Cleanup()
 
 	// Создаем игрока в начальной позиции
-	playerStartPos := mgl32.Vec3{5, 9, 5}
+	playerStartPos := mgl32.Vec3{5, 11, 5}
 	gameInstance.CreatePlayer(playerStartPos)
 
 	// Настраиваем обработчики ввода
 	gameInstance.SetupInputHandlers()
 
 	// Загружаем игровой мир
-	gameInstance.LoadWorld()
+	if err := gameInstance.LoadWorld(); err != nil {
+		log.Fatalf("Ошибка загрузки мира: %v", err)
+	}
 
 	// Запускаем игровой цикл
 	gameInstance.Start()
@@ -20,16 +20,33 @@ type Game struct {
 	World         *world.World
 	Player        *Player
 	PhysicsEngine *physics.PhysicsEngine
+	FallingBlocks *world.FallingBlocks
 
 	Running      bool
 	LastTime     time.Time
 	ShowControls bool // Флаг для отображения управления
+
+	// Частота тиков симуляции (тиков в секунду)
+	TickRate int
+
+	// Накопленное, но еще не просимулированное время кадров
+	accumulator float64
+
+	// Записываемый журнал физики (nil - запись не ведется)
+	trace *traceFile
 }
 
 // Константы для управления игрой
 const (
 	// Расстояние генерации чанков от центра (было 2)
 	ChunkDistance = 1
+
+	// Частота тиков симуляции по умолчанию
+	DefaultTickRate = 60
+
+	// Максимальное время кадра, учитываемое симуляцией. Защищает от лавинообразного
+	// накопления тиков после долгих пауз (загрузка, перетаскивание окна)
+	MaxFrameTime = 0.25
 )
 
 // NewGame создает новую игру
@@ -43,8 +60,11 @@ func NewGame(win *window.Window) (*Game, error) {
 	// Создаем мир
 	w := world.NewWorld()
 
-	// Создаем физический движок
-	physicsEngine := physics.NewPhysicsEngine()
+	// Создаем физический движок, сталкивающий тела с блоками мира
+	physicsEngine := physics.NewPhysicsEngine(w)
+
+	// Песок и гравий без опоры падают как физические тела
+	fallingBlocks := world.NewFallingBlocks(w, physicsEngine)
 
 	// Создаем игру
 	g := &Game{
@@ -52,18 +72,31 @@ func NewGame(win *window.Window) (*Game, error) {
 		Renderer:      renderer,
 		World:         w,
 		PhysicsEngine: physicsEngine,
+		FallingBlocks: fallingBlocks,
 		Running:       false,
 		LastTime:      time.Now(),
+		TickRate:      DefaultTickRate,
 	}
 
-	// Загружаем мир
-	g.LoadWorld()
+	// Изменение блока будит уснувшие рядом с ним тела
+	w.OnBlockChanged = func(pos world.BlockPos) {
+		corner := mgl32.Vec3{float32(pos.X), float32(pos.Y), float32(pos.Z)}
+		physicsEngine.WakeRegion(physics.NewBox(corner, corner.Add(mgl32.Vec3{1, 1, 1})))
 
-	// Создаем игрока в центре мира
-	g.CreatePlayer(mgl32.Vec3{0, 5, 0})
+		// Журнал физики воспроизводится в мире, сохраненном в начале записи, поэтому запись останавливается
+		if g.trace != nil {
+			fmt.Println("Мир изменился, запись журнала физики остановлена")
+			g.toggleTrace()
+		}
+	}
+
+	// Загружаем мир
+	if err := g.LoadWorld(); err != nil {
+		return nil, fmt.Errorf("Ошибка загрузки мира: %v", err)
+	}
 
-	// Регистрируем физическое тело игрока в движке
-	g.PhysicsEngine.Register(g.Player.Body)
+	// Создаем игрока над полом в начале мира
+	g.CreatePlayer(mgl32.Vec3{2, 11, 2})
 
 	return g, nil
 }
@@ -94,13 +127,18 @@ func (g *Game) SetupInputHandlers() {
 
 // CreatePlayer создает игрока
 func (g *Game) CreatePlayer(position mgl32.Vec3) {
+	// Прежний игрок больше не участвует в симуляции
+	if g.Player != nil {
+		g.PhysicsEngine.UnregisterController(g.Player.Controller)
+	}
+
 	g.Player = NewPlayer(position)
-	// Регистрируем тело игрока в физическом движке
-	g.PhysicsEngine.Register(g.Player.Body)
+	// Регистрируем контроллер и тело игрока в физическом движке
+	g.PhysicsEngine.RegisterController(g.Player.Controller)
 }
 
 // LoadWorld загружает игровой мир
-func (g *Game) LoadWorld() {
+func (g *Game) LoadWorld() error {
 	// Генерируем центральный чанк
 	chunk := world.NewChunk(mgl32.Vec3{0, 0, 0})
 
@@ -112,19 +150,25 @@ func (g *Game) LoadWorld() {
 		for j := 0; j < world.ChunkWidth; j++ {
 			// Создаем базовую поверхность с толщиной
 			for y := 0; y < FloorThickness; y++ {
-				chunk.SetBlock(i, y, j, "stone", true)
+				if err := chunk.SetBlock(i, y, j, "stone", true); err != nil {
+					return err
+				}
 			}
 
 			// Создаем лестницу, но только в некоторых местах
 			if i == 12 && j > 5 && j < 10 {
 				for h := FloorThickness; h <= FloorThickness+3; h++ {
-					chunk.SetBlock(i, h, j, "brick", true)
+					if err := chunk.SetBlock(i, h, j, "brick", true); err != nil {
+						return err
+					}
 				}
 			}
 
 			// Создаем небольшую платформу
 			if i >= 6 && i <= 9 && j >= 6 && j <= 9 {
-				chunk.SetBlock(i, FloorThickness, j, "brick", true)
+				if err := chunk.SetBlock(i, FloorThickness, j, "brick", true); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -146,7 +190,9 @@ func (g *Game) LoadWorld() {
 	for i := 0; i < world.ChunkWidth; i++ {
 		for j := 0; j < world.ChunkWidth; j++ {
 			for y := 0; y < FloorThickness; y++ {
-				otherChunk.SetBlock(i, y, j, "stone", true)
+				if err := otherChunk.SetBlock(i, y, j, "stone", true); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -159,13 +205,16 @@ func (g *Game) LoadWorld() {
 	for h := FloorThickness; h < FloorThickness+3; h++ {
 		for i := centerX - 1; i <= centerX+1; i++ {
 			for j := centerZ - 1; j <= centerZ+1; j++ {
-				otherChunk.SetBlock(i, h, j, "brick", true)
+				if err := otherChunk.SetBlock(i, h, j, "brick", true); err != nil {
+					return err
+				}
 			}
 		}
 	}
 
 	// Добавляем чанк в мир
 	g.World.AddChunk(otherChunk)
+	return nil
 }
 
 // GetControlKeys возвращает список кнопок управления
@@ -176,33 +225,35 @@ func (g *Game) GetControlKeys() []struct{ Key, Desc string } {
 		{"S", "Движение назад"},
 		{"D", "Движение вправо"},
 		{"Space", "Прыжок / Полет вверх"},
-		{"Shift", "Полет вниз (в режиме полета)"},
+		{"Ctrl", "Бег"},
+		{"Shift", "Присесть / Полет вниз (в режиме полета)"},
 		{"F", "Переключение режима полета"},
 		{"Escape", "Выход из игры"},
 		{"H", "Показать/скрыть это меню"},
+		{"F9", "Начать/остановить запись журнала физики"},
 	}
 }
 
 // ProcessInput обрабатывает пользовательский ввод
-func (g *Game) ProcessInput() (forward, right, up float32) {
+func (g *Game) ProcessInput() (input physics.MovementInput) {
 	// Обрабатываем ввод
 	if g.Window.IsPressed(glfw.KeyW) {
-		forward += 1.0
+		input.Forward += 1.0
 	}
 	if g.Window.IsPressed(glfw.KeyS) {
-		forward -= 1.0
+		input.Forward -= 1.0
 	}
 	if g.Window.IsPressed(glfw.KeyD) {
-		right += 1.0
+		input.Right += 1.0
 	}
 	if g.Window.IsPressed(glfw.KeyA) {
-		right -= 1.0
+		input.Right -= 1.0
 	}
 
-	// Прыжок
-	if g.Window.IsPressed(glfw.KeySpace) && g.Player.OnGround {
-		g.Player.Jump()
-	}
+	// Прыжок, бег и приседание. Прыжок запоминается контроллером и выполняется при касании земли
+	input.Jump = g.Window.IsPressed(glfw.KeySpace)
+	input.Sprint = g.Window.IsPressed(glfw.KeyLeftControl)
+	input.Crouch = g.Window.IsPressed(glfw.KeyLeftShift)
 
 	// Переключение режима полета - временно отключаем
 	// if g.Window.Debounce(glfw.KeyF) {
@@ -225,31 +276,70 @@ func (g *Game) ProcessInput() (forward, right, up float32) {
 		g.ShowControls = !g.ShowControls
 	}
 
+	// Запись журнала физики
+	if g.Window.Debounce(glfw.KeyF9) {
+		g.toggleTrace()
+	}
+
 	// Выход из игры
 	if g.Window.IsPressed(glfw.KeyEscape) {
 		g.Window.GetGLFWWindow().SetShouldClose(true)
 	}
 
-	return forward, right, up
+	return input
 }
 
 // UpdatePhysics обновляет физику игры
-func (g *Game) UpdatePhysics(delta float64, forward, right, up float32) {
-	// Обновляем движение игрока
-	if forward != 0 {
-		g.Player.MoveForward(float64(forward) * delta)
-	}
+func (g *Game) UpdatePhysics(delta float64, input physics.MovementInput) {
+	// Передаем ввод контроллеру игрока
+	g.Player.ApplyInput(input)
+
+	// Продвигаем симуляцию: контроллер задает скорость игрока, движок применяет гравитацию
+	// и разрешает коллизии с миром
+	g.PhysicsEngine.Tick(delta)
 
-	if right != 0 {
-		g.Player.MoveRight(float64(right) * delta)
+	// Запись журнала, прерванная движком (например, падающий блок изменил состав тел), завершается
+	if g.trace != nil && g.PhysicsEngine.TraceError() != nil {
+		g.toggleTrace()
 	}
 
 	// Обновляем состояние игрока
-	g.Player.Update(delta, g.World)
+	g.Player.Update(delta)
+}
+
+// TickDelta возвращает длительность одного тика симуляции в секундах.
+// Неположительная частота TickRate заменяется на DefaultTickRate.
+func (g *Game) TickDelta() float64 {
+	rate := g.TickRate
+	if rate <= 0 {
+		rate = DefaultTickRate
+	}
+	return 1.0 / float64(rate)
 }
 
-// Render отрисовывает текущий кадр
-func (g *Game) Render() {
+// Advance накапливает время кадра и выполняет столько тиков фиксированной длины, сколько в него помещается.
+// Возвращает долю следующего тика (0..1), на которую нужно интерполировать отрисовку.
+func (g *Game) Advance(frameDelta float64, input physics.MovementInput) float32 {
+	if frameDelta > MaxFrameTime {
+		frameDelta = MaxFrameTime
+	}
+	g.accumulator += frameDelta
+
+	tickDelta := g.TickDelta()
+	for g.accumulator >= tickDelta {
+		g.UpdatePhysics(tickDelta, input)
+		g.accumulator -= tickDelta
+	}
+
+	return float32(g.accumulator / tickDelta)
+}
+
+// Render отрисовывает текущий кадр.
+// alpha - доля следующего тика, на которую интерполируются положения тел.
+func (g *Game) Render(alpha float32) {
+	// Камера следует за интерполированным положением игрока
+	g.Player.Interpolate(alpha)
+
 	// Обновляем вид камеры в рендерере
 	g.Renderer.SetCamera(
 		g.Player.Camera.GetPosition(),
@@ -268,9 +358,10 @@ func (g *Game) Render() {
 		g.Renderer.DrawChunk(chunk)
 	}
 
-	// Отрисовываем коллайдер игрока
+	// Отрисовываем коллайдер игрока в интерполированном положении
 	if g.Player.Body.Collider != nil {
-		g.Renderer.DrawBox(*g.Player.Body.Collider, mgl32.Vec3{1.0, 0.0, 0.0}) // Красный цвет для игрока
+		offset := g.Player.Body.InterpolatedPosition(alpha).Sub(g.Player.Body.Position)
+		g.Renderer.DrawBox(g.Player.Body.Collider.Translate(offset), mgl32.Vec3{1.0, 0.0, 0.0}) // Красный цвет для игрока
 	}
 
 	// Отрисовываем таблицу с управлением
@@ -343,45 +434,27 @@ func (g *Game) GetVisibleChunks() []*world.Chunk {
 	*/
 }
 
-// Update обновляет состояние игры
+// Update обновляет состояние игры за один кадр
 func (g *Game) Update(delta float64) {
 	// Обрабатываем ввод
-	forward, right, up := g.ProcessInput()
+	input := g.ProcessInput()
 
-	// Обновляем физику
-	g.UpdatePhysics(delta, forward, right, up)
+	// Продвигаем симуляцию фиксированными тиками
+	alpha := g.Advance(delta, input)
 
-	// Обновляем камеру для рендеринга
-	g.Renderer.SetCamera(g.Player.Camera.GetPosition(), g.Player.Camera.GetTarget(), g.Player.Camera.GetUp())
-
-	// Отрисовываем мир
-	g.Renderer.Begin()
-
-	// Отрисовываем все чанки
-	chunks := g.World.GetAllChunks()
-	for _, chunk := range chunks {
-		g.Renderer.DrawChunk(chunk)
-	}
-
-	// Отображаем управление, если включено
-	if g.ShowControls {
-		g.Renderer.DrawControls(g.GetControlKeys())
-	}
-
-	g.Renderer.End()
+	// Отрисовываем сцену с интерполяцией между тиками
+	g.Render(alpha)
 
 	// Обновляем окно
 	g.Window.Update()
 }
 
-// Start запускает игровой цикл
+// Start запускает игровой цикл.
+// Симуляция идет с частотой TickRate независимо от частоты кадров.
 func (g *Game) Start() {
 	g.Running = true
 	g.LastTime = time.Now()
-
-	// Максимальный шаг времени для физики (в секундах)
-	// Делаем крайне маленьким для максимальной надежности
-	const maxDeltaTime = 0.016 // Примерно 60 FPS
+	g.accumulator = 0
 
 	// Счетчик FPS для отладки
 	frameCount := 0
@@ -404,23 +477,8 @@ func (g *Game) Start() {
 			fmt.Printf("FPS: %d\n", displayFPS)
 		}
 
-		// Ограничиваем максимальную дельту времени
-		if delta > maxDeltaTime {
-			delta = maxDeltaTime
-		}
-
-		// Обновляем состояние игры
-		// Обрабатываем ввод
-		forward, right, up := g.ProcessInput()
-
-		// Обновляем физику
-		g.UpdatePhysics(delta, forward, right, up)
-
-		// Отрисовываем сцену
-		g.Render()
-
-		// Обновляем окно
-		g.Window.Update()
+		// Обрабатываем ввод, продвигаем симуляцию и отрисовываем кадр
+		g.Update(delta)
 
 		// Ограничение скорости цикла для стабильности
 		runtime.Gosched()
@@ -440,6 +498,9 @@ func (g *Game) Stop() {
 
 // Cleanup освобождает ресурсы игры
 func (g *Game) Cleanup() {
+	if err := g.StopTrace(); err != nil {
+		fmt.Printf("Ошибка записи журнала физики: %v\n", err)
+	}
 	if g.Renderer != nil {
 		g.Renderer.Destroy()
 	}
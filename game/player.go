@@ -1,11 +1,8 @@
 package game
 
 import (
-	"fmt"
-
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/user/gengine/physics"
-	"github.com/user/gengine/world"
 )
 
 // Player представляет игрока в игровом мире
@@ -16,6 +13,7 @@ type Player struct {
 	Height     float32
 	Width      float32
 	OnGround   bool
+	Crouching  bool
 }
 
 // DefaultPlayerHeight определяет высоту игрока
@@ -27,11 +25,11 @@ const DefaultPlayerWidth = 0.6
 // DefaultPlayerMass определяет массу игрока
 const DefaultPlayerMass = 70.0
 
-// DefaultPlayerSpeed определяет базовую скорость игрока
-const DefaultPlayerSpeed = 1.0
+// DefaultPlayerSpeed определяет скорость ходьбы игрока (м/с)
+const DefaultPlayerSpeed = 4.3
 
-// DefaultJumpForce определяет силу прыжка
-const DefaultJumpForce = 4.0
+// DefaultJumpForce определяет начальную вертикальную скорость прыжка (м/с)
+const DefaultJumpForce = 5.5
 
 // NewPlayer создает нового игрока
 func NewPlayer(position mgl32.Vec3) *Player {
@@ -40,8 +38,9 @@ func NewPlayer(position mgl32.Vec3) *Player {
 
 	// Установка кастомных физических параметров для лучшей игровой физики
 	body.JumpSpeed = DefaultJumpForce
+	body.StepHeight = physics.DefaultCharacterStepHeight
 
-	// Создаем контроллер движения с улучшенной скоростью
+	// Создаем контроллер движения, управляющий скоростью тела
 	controller := physics.NewMovementController(body, DefaultPlayerSpeed, DefaultJumpForce)
 
 	// Создаем камеру на уровне глаз
@@ -58,78 +57,37 @@ func NewPlayer(position mgl32.Vec3) *Player {
 	}
 }
 
-// Update обновляет состояние игрока
-func (p *Player) Update(delta float64, world *world.World) {
-	// Обновляем физические параметры и позицию
-	p.checkGrounded(world)
+// Update обновляет состояние игрока после тика физики
+func (p *Player) Update(delta float64) {
+	// Касание земли определяет физический движок при разрешении коллизий
+	p.OnGround = p.Body.Grounded
+	p.Crouching = p.Controller.Crouching
 
 	// Обновляем позицию камеры на основе позиции тела
-	eyeHeight := p.Height * 0.85 // 85% от высоты для глаз
-	p.Camera.UpdatePosition(p.Body.Position.Add(mgl32.Vec3{0, eyeHeight, 0}))
+	p.Interpolate(1)
 }
 
-// Отдельный метод для проверки касания земли с запасом
-func (p *Player) checkGrounded(world *world.World) {
-	// Сначала используем стандартное определение "на земле" из физики
-	p.OnGround = p.Body.Grounded
-
-	// Дополнительная проверка блоков под игроком
-	footPosition := p.Body.Position
-	footPosition[1] -= 0.05 // Небольшой запас вниз
-
-	// Проверяем центр и углы под игроком для надежности
-	halfWidth := p.Width * 0.45
-
-	// Точки для проверки
-	checkPoints := []mgl32.Vec3{
-		footPosition, // Центр
-		{footPosition.X() - halfWidth, footPosition.Y(), footPosition.Z() - halfWidth},
-		{footPosition.X() + halfWidth, footPosition.Y(), footPosition.Z() - halfWidth},
-		{footPosition.X() - halfWidth, footPosition.Y(), footPosition.Z() + halfWidth},
-		{footPosition.X() + halfWidth, footPosition.Y(), footPosition.Z() + halfWidth},
-	}
-
-	// Проверяем все точки
-	for _, point := range checkPoints {
-		block := world.GetBlock(point)
-		if block != nil && block.Active {
-			p.OnGround = true
-			return
-		}
-	}
+// Interpolate устанавливает камеру между предыдущим и текущим положением тела.
+// alpha - доля тика, прошедшая с последнего обновления физики.
+func (p *Player) Interpolate(alpha float32) {
+	p.Camera.UpdatePosition(p.Body.InterpolatedPosition(alpha).Add(mgl32.Vec3{0, p.EyeHeight(), 0}))
 }
 
-// Jump заставляет игрока прыгнуть
-func (p *Player) Jump() {
-	// Используем и собственную проверку, и проверку из физики
-	if p.OnGround || p.Body.Grounded {
-		// Дополнительно логируем прыжок для отладки
-		fmt.Println("[DEBUG] Игрок прыгнул")
-		p.Body.Jump()
-		p.OnGround = false
-	}
+// EyeHeight возвращает высоту глаз над ногами; при приседании глаза опускаются вместе с коллайдером
+func (p *Player) EyeHeight() float32 {
+	return p.Body.Height * 0.85 // 85% от высоты для глаз
 }
 
-// MoveForward перемещает игрока вперед
-func (p *Player) MoveForward(amount float64) {
-	// Получаем направление "вперед" из камеры, но обнуляем Y
-	forward := p.Camera.GetFront()
-	forward[1] = 0 // Обнуляем Y для движения по плоскости
-	if forward.Len() > 0 {
-		forward = forward.Normalize()
-	}
-
-	// Применяем движение через контроллер
-	p.Controller.Update(float32(amount), 0, 0, forward, p.Camera.GetRight())
+// Jump запрашивает прыжок; контроллер выполнит его, когда игрок окажется на земле
+func (p *Player) Jump() {
+	p.Controller.Jump()
 }
 
-// MoveRight перемещает игрока вправо
-func (p *Player) MoveRight(amount float64) {
-	// Получаем направление "вправо" из камеры
-	right := p.Camera.GetRight()
-
-	// Применяем движение через контроллер
-	p.Controller.Update(0, float32(amount), 0, p.Camera.GetFront(), right)
+// ApplyInput передает ввод контроллеру движения, задавая направления по камере
+func (p *Player) ApplyInput(input physics.MovementInput) {
+	input.ViewVector = p.Camera.GetFront()
+	input.RightVector = p.Camera.GetRight()
+	p.Controller.SetInput(input)
 }
 
 // ProcessMouseMovement обрабатывает движение мыши для камеры
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Каталог, в который записываются журналы физики
+const TraceDir = "traces"
+
+// traceFile - открытый журнал физики
+type traceFile struct {
+	file   *os.File
+	writer *bufio.Writer
+	dir    string
+}
+
+// StartTrace сохраняет мир в каталог и начинает запись журнала физики в файл trace.bin в нем.
+// Журнал воспроизводится командой cmd/physreplay.
+func (g *Game) StartTrace(dir string) error {
+	if g.trace != nil {
+		return fmt.Errorf("Журнал физики уже записывается в %s", g.trace.dir)
+	}
+	if err := g.World.Save(dir); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(dir, "trace.bin"))
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	if err := g.PhysicsEngine.StartTrace(writer); err != nil {
+		file.Close()
+		return err
+	}
+
+	g.trace = &traceFile{file: file, writer: writer, dir: dir}
+	return nil
+}
+
+// StopTrace завершает запись журнала физики
+func (g *Game) StopTrace() error {
+	if g.trace == nil {
+		return nil
+	}
+	trace := g.trace
+	g.trace = nil
+
+	err := g.PhysicsEngine.StopTrace()
+	if flushErr := trace.writer.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := trace.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// toggleTrace начинает или завершает запись журнала физики в новый каталог внутри TraceDir
+func (g *Game) toggleTrace() {
+	if g.trace != nil {
+		dir := g.trace.dir
+		if err := g.StopTrace(); err != nil {
+			fmt.Printf("Ошибка записи журнала физики: %v\n", err)
+			return
+		}
+		fmt.Printf("Журнал физики записан в %s\n", dir)
+		return
+	}
+
+	dir := filepath.Join(TraceDir, time.Now().Format("20060102-150405"))
+	if err := g.StartTrace(dir); err != nil {
+		fmt.Printf("Ошибка начала записи журнала физики: %v\n", err)
+		return
+	}
+	fmt.Printf("Запись журнала физики в %s\n", dir)
+}
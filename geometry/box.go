@@ -0,0 +1,354 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Epsilon допуск при сравнении граней боксов, компенсирующий ошибки округления
+const Epsilon = 1e-4
+
+// Box представляет собой AABB (ось-ориентированный ограничивающий бокс)
+type Box struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// NewBox создает новый бокс по минимальной и максимальной точкам
+func NewBox(min, max mgl32.Vec3) Box {
+	return Box{
+		Min: min,
+		Max: max,
+	}
+}
+
+// BoxAround создает бокс с центром в точке и заданными половинными размерами
+func BoxAround(center, halfSize mgl32.Vec3) Box {
+	return Box{
+		Min: center.Sub(halfSize),
+		Max: center.Add(halfSize),
+	}
+}
+
+// Center возвращает центр бокса
+func (b Box) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Size возвращает размеры бокса по осям
+func (b Box) Size() mgl32.Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+// Volume возвращает объем бокса (0 для пустого бокса)
+func (b Box) Volume() float32 {
+	size := b.Size()
+	if size.X() <= 0 || size.Y() <= 0 || size.Z() <= 0 {
+		return 0
+	}
+	return size.X() * size.Y() * size.Z()
+}
+
+// IsEmpty проверяет, что бокс не имеет объема
+func (b Box) IsEmpty() bool {
+	return b.Volume() == 0
+}
+
+// Contains проверяет, лежит ли точка внутри бокса или на его границе
+func (b Box) Contains(p mgl32.Vec3) bool {
+	return p.X() >= b.Min.X() && p.X() <= b.Max.X() &&
+		p.Y() >= b.Min.Y() && p.Y() <= b.Max.Y() &&
+		p.Z() >= b.Min.Z() && p.Z() <= b.Max.Z()
+}
+
+// ContainsBox проверяет, лежит ли другой бокс целиком внутри этого
+func (b Box) ContainsBox(other Box) bool {
+	return b.Contains(other.Min) && b.Contains(other.Max)
+}
+
+// Overlaps проверяет строгое пересечение двух боксов (касание гранями не считается)
+func (b Box) Overlaps(other Box) bool {
+	return b.Min.X() < other.Max.X() && b.Max.X() > other.Min.X() &&
+		b.Min.Y() < other.Max.Y() && b.Max.Y() > other.Min.Y() &&
+		b.Min.Z() < other.Max.Z() && b.Max.Z() > other.Min.Z()
+}
+
+// Union возвращает наименьший бокс, содержащий оба бокса
+func (b Box) Union(other Box) Box {
+	return Box{
+		Min: mgl32.Vec3{minf(b.Min.X(), other.Min.X()), minf(b.Min.Y(), other.Min.Y()), minf(b.Min.Z(), other.Min.Z())},
+		Max: mgl32.Vec3{maxf(b.Max.X(), other.Max.X()), maxf(b.Max.Y(), other.Max.Y()), maxf(b.Max.Z(), other.Max.Z())},
+	}
+}
+
+// Intersect возвращает общую часть двух боксов. Если боксы не пересекаются, результат пуст (IsEmpty).
+func (b Box) Intersect(other Box) Box {
+	return Box{
+		Min: mgl32.Vec3{maxf(b.Min.X(), other.Min.X()), maxf(b.Min.Y(), other.Min.Y()), maxf(b.Min.Z(), other.Min.Z())},
+		Max: mgl32.Vec3{minf(b.Max.X(), other.Max.X()), minf(b.Max.Y(), other.Max.Y()), minf(b.Max.Z(), other.Max.Z())},
+	}
+}
+
+// Expand возвращает бокс, расширенный на amount в обе стороны по каждой оси.
+// Отрицательные значения сжимают бокс.
+func (b Box) Expand(amount mgl32.Vec3) Box {
+	return Box{
+		Min: b.Min.Sub(amount),
+		Max: b.Max.Add(amount),
+	}
+}
+
+// Grow возвращает бокс, расширенный на одно и то же значение по всем осям
+func (b Box) Grow(amount float32) Box {
+	return b.Expand(mgl32.Vec3{amount, amount, amount})
+}
+
+// ClosestPoint возвращает точку бокса, ближайшую к заданной
+func (b Box) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{
+		clampf(p.X(), b.Min.X(), b.Max.X()),
+		clampf(p.Y(), b.Min.Y(), b.Max.Y()),
+		clampf(p.Z(), b.Min.Z(), b.Max.Z()),
+	}
+}
+
+// Distance вычисляет минимальное расстояние от точки до бокса
+func (b Box) Distance(p mgl32.Vec3) float32 {
+	// Для каждой оси находим ближайшую точку бокса к заданной точке
+	var distSq float32 = 0
+
+	// X-axis
+	if p.X() < b.Min.X() {
+		distSq += (b.Min.X() - p.X()) * (b.Min.X() - p.X())
+	} else if p.X() > b.Max.X() {
+		distSq += (p.X() - b.Max.X()) * (p.X() - b.Max.X())
+	}
+
+	// Y-axis
+	if p.Y() < b.Min.Y() {
+		distSq += (b.Min.Y() - p.Y()) * (b.Min.Y() - p.Y())
+	} else if p.Y() > b.Max.Y() {
+		distSq += (p.Y() - b.Max.Y()) * (p.Y() - b.Max.Y())
+	}
+
+	// Z-axis
+	if p.Z() < b.Min.Z() {
+		distSq += (b.Min.Z() - p.Z()) * (b.Min.Z() - p.Z())
+	} else if p.Z() > b.Max.Z() {
+		distSq += (p.Z() - b.Max.Z()) * (p.Z() - b.Max.Z())
+	}
+
+	return float32(math.Sqrt(float64(distSq)))
+}
+
+// CombineY создает новый бокс, объединяющий этот и другой бокс по оси Y
+func (b Box) CombineY(other Box) Box {
+	return Box{
+		Min: mgl32.Vec3{
+			b.Min.X(),
+			minf(b.Min.Y(), other.Min.Y()),
+			b.Min.Z(),
+		},
+		Max: mgl32.Vec3{
+			b.Max.X(),
+			maxf(b.Max.Y(), other.Max.Y()),
+			b.Max.Z(),
+		},
+	}
+}
+
+// Corners возвращает все 8 углов бокса
+func (b Box) Corners() []mgl32.Vec3 {
+	return []mgl32.Vec3{
+		{b.Min.X(), b.Min.Y(), b.Min.Z()},
+		{b.Min.X(), b.Min.Y(), b.Max.Z()},
+		{b.Min.X(), b.Max.Y(), b.Min.Z()},
+		{b.Min.X(), b.Max.Y(), b.Max.Z()},
+		{b.Max.X(), b.Min.Y(), b.Min.Z()},
+		{b.Max.X(), b.Min.Y(), b.Max.Z()},
+		{b.Max.X(), b.Max.Y(), b.Min.Z()},
+		{b.Max.X(), b.Max.Y(), b.Max.Z()},
+	}
+}
+
+// IntersectionXZ вычисляет пересечение по осям X и Z
+func (b Box) IntersectionXZ(other Box) (bool, mgl32.Vec3) {
+	// Проверяем пересечение по X и Z
+	if b.Max.X() < other.Min.X() || b.Min.X() > other.Max.X() ||
+		b.Max.Z() < other.Min.Z() || b.Min.Z() > other.Max.Z() {
+		return false, mgl32.Vec3{}
+	}
+
+	// Вычисляем глубину проникновения по X
+	dx1 := other.Max.X() - b.Min.X()
+	dx2 := b.Max.X() - other.Min.X()
+	dx := minf(dx1, dx2)
+
+	// Вычисляем глубину проникновения по Z
+	dz1 := other.Max.Z() - b.Min.Z()
+	dz2 := b.Max.Z() - other.Min.Z()
+	dz := minf(dz1, dz2)
+
+	// Выбираем наименьшую глубину проникновения
+	var penetration mgl32.Vec3
+	if dx < dz {
+		penetration = mgl32.Vec3{dx * signF(dx1-dx2), 0, 0}
+	} else {
+		penetration = mgl32.Vec3{0, 0, dz * signF(dz1-dz2)}
+	}
+
+	return true, penetration
+}
+
+// IntersectionY вычисляет пересечение по оси Y
+func (b Box) IntersectionY(other Box) (bool, float32) {
+	// Проверяем пересечение по X и Z
+	if b.Max.X() < other.Min.X() || b.Min.X() > other.Max.X() ||
+		b.Max.Z() < other.Min.Z() || b.Min.Z() > other.Max.Z() {
+		return false, 0
+	}
+
+	// Вычисляем глубину проникновения по Y
+	if b.Min.Y() > other.Max.Y() || b.Max.Y() < other.Min.Y() {
+		return false, 0
+	}
+
+	dy1 := other.Max.Y() - b.Min.Y()
+	dy2 := b.Max.Y() - other.Min.Y()
+	dy := minf(dy1, dy2)
+
+	return true, dy
+}
+
+// Intersection проверяет пересечение этого бокса с другим и возвращает глубину проникновения по всем осям
+func (b Box) Intersection(other Box) (bool, mgl32.Vec3) {
+	// Проверяем пересечение по всем осям
+	if b.Max.X() < other.Min.X() || b.Min.X() > other.Max.X() ||
+		b.Max.Y() < other.Min.Y() || b.Min.Y() > other.Max.Y() ||
+		b.Max.Z() < other.Min.Z() || b.Min.Z() > other.Max.Z() {
+		return false, mgl32.Vec3{}
+	}
+
+	// Вычисляем глубину проникновения по X
+	dx1 := other.Max.X() - b.Min.X()
+	dx2 := b.Max.X() - other.Min.X()
+	dx := minf(dx1, dx2)
+
+	// Вычисляем глубину проникновения по Y
+	dy1 := other.Max.Y() - b.Min.Y()
+	dy2 := b.Max.Y() - other.Min.Y()
+	dy := minf(dy1, dy2)
+
+	// Вычисляем глубину проникновения по Z
+	dz1 := other.Max.Z() - b.Min.Z()
+	dz2 := b.Max.Z() - other.Min.Z()
+	dz := minf(dz1, dz2)
+
+	// Выбираем наименьшую глубину проникновения
+	var penetration mgl32.Vec3
+	if dx < dy && dx < dz {
+		penetration = mgl32.Vec3{dx * signF(dx1-dx2), 0, 0}
+	} else if dy < dx && dy < dz {
+		penetration = mgl32.Vec3{0, dy * signF(dy1-dy2), 0}
+	} else {
+		penetration = mgl32.Vec3{0, 0, dz * signF(dz1-dz2)}
+	}
+
+	return true, penetration
+}
+
+// Translate возвращает бокс, смещенный на вектор
+func (b Box) Translate(offset mgl32.Vec3) Box {
+	return Box{
+		Min: b.Min.Add(offset),
+		Max: b.Max.Add(offset),
+	}
+}
+
+// Stretch возвращает бокс, растянутый в направлении вектора движения.
+// Результат покрывает все положения бокса на пути движения.
+func (b Box) Stretch(movement mgl32.Vec3) Box {
+	result := b
+	for axis := 0; axis < 3; axis++ {
+		if movement[axis] < 0 {
+			result.Min[axis] += movement[axis]
+		} else {
+			result.Max[axis] += movement[axis]
+		}
+	}
+	return result
+}
+
+// ClipAxis ограничивает смещение движущегося бокса вдоль оси (0 - X, 1 - Y, 2 - Z),
+// чтобы он не вошел в этот бокс. Если боксы не перекрываются по остальным осям
+// или движущийся бокс уже пересекает этот, смещение возвращается без изменений.
+func (b Box) ClipAxis(moving Box, axis int, offset float32) float32 {
+	for other := 0; other < 3; other++ {
+		if other == axis {
+			continue
+		}
+		if moving.Max[other] <= b.Min[other]+Epsilon || moving.Min[other] >= b.Max[other]-Epsilon {
+			return offset
+		}
+	}
+
+	if offset > 0 && moving.Max[axis] <= b.Min[axis]+Epsilon {
+		if d := b.Min[axis] - moving.Max[axis]; d < offset {
+			offset = d
+		}
+	} else if offset < 0 && moving.Min[axis] >= b.Max[axis]-Epsilon {
+		if d := b.Max[axis] - moving.Min[axis]; d > offset {
+			offset = d
+		}
+	}
+
+	return offset
+}
+
+// IntersectRay проверяет пересечение луча с боксом методом пластин.
+// Возвращает параметр t точки входа (в единицах direction) и нормаль грани входа.
+// Если начало луча внутри бокса, возвращает t = 0 и нулевую нормаль.
+func (b Box) IntersectRay(origin, direction mgl32.Vec3) (float32, mgl32.Vec3, bool) {
+	tNear := float32(math.Inf(-1))
+	tFar := float32(math.Inf(1))
+	var normal mgl32.Vec3
+
+	for axis := 0; axis < 3; axis++ {
+		if direction[axis] == 0 {
+			// Луч параллелен пластинам: начало должно лежать между ними
+			if origin[axis] < b.Min[axis] || origin[axis] > b.Max[axis] {
+				return 0, mgl32.Vec3{}, false
+			}
+			continue
+		}
+
+		inv := 1 / direction[axis]
+		t1 := (b.Min[axis] - origin[axis]) * inv
+		t2 := (b.Max[axis] - origin[axis]) * inv
+
+		// Нормаль грани входа направлена против луча
+		faceSign := float32(-1)
+		if t1 > t2 {
+			t1, t2 = t2, t1
+			faceSign = 1
+		}
+
+		if t1 > tNear {
+			tNear = t1
+			normal = mgl32.Vec3{}
+			normal[axis] = faceSign
+		}
+		if t2 < tFar {
+			tFar = t2
+		}
+		if tNear > tFar || tFar < 0 {
+			return 0, mgl32.Vec3{}, false
+		}
+	}
+
+	if tNear < 0 {
+		return 0, mgl32.Vec3{}, true
+	}
+	return tNear, normal, true
+}
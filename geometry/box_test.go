@@ -0,0 +1,239 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// unitBox бокс от (0, 0, 0) до (1, 1, 1)
+var unitBox = NewBox(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 1, 1})
+
+// approx сравнивает числа с допуском
+func approx(a, b float32) bool {
+	return absf(a-b) <= 1e-4
+}
+
+// approxVec сравнивает векторы с допуском
+func approxVec(a, b mgl32.Vec3) bool {
+	return approx(a.X(), b.X()) && approx(a.Y(), b.Y()) && approx(a.Z(), b.Z())
+}
+
+func TestBoxCenterSizeVolume(t *testing.T) {
+	tests := []struct {
+		name   string
+		box    Box
+		center mgl32.Vec3
+		size   mgl32.Vec3
+		volume float32
+	}{
+		{"единичный", unitBox, mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{1, 1, 1}, 1},
+		{"вытянутый", NewBox(mgl32.Vec3{-1, 0, 2}, mgl32.Vec3{1, 4, 3}), mgl32.Vec3{0, 2, 2.5}, mgl32.Vec3{2, 4, 1}, 8},
+		{"плоский", NewBox(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{1, 1, 1}), mgl32.Vec3{0.5, 1, 0.5}, mgl32.Vec3{1, 0, 1}, 0},
+		{"вывернутый", NewBox(mgl32.Vec3{1, 1, 1}, mgl32.Vec3{0, 0, 0}), mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{-1, -1, -1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.box.Center(); !approxVec(got, tt.center) {
+				t.Errorf("Center() = %v, ожидалось %v", got, tt.center)
+			}
+			if got := tt.box.Size(); !approxVec(got, tt.size) {
+				t.Errorf("Size() = %v, ожидалось %v", got, tt.size)
+			}
+			if got := tt.box.Volume(); !approx(got, tt.volume) {
+				t.Errorf("Volume() = %v, ожидалось %v", got, tt.volume)
+			}
+			if got := tt.box.IsEmpty(); got != (tt.volume == 0) {
+				t.Errorf("IsEmpty() = %v", got)
+			}
+		})
+	}
+}
+
+func TestBoxContains(t *testing.T) {
+	tests := []struct {
+		name  string
+		point mgl32.Vec3
+		want  bool
+	}{
+		{"центр", mgl32.Vec3{0.5, 0.5, 0.5}, true},
+		{"угол", mgl32.Vec3{0, 0, 0}, true},
+		{"грань", mgl32.Vec3{1, 0.5, 0.5}, true},
+		{"снаружи по X", mgl32.Vec3{1.01, 0.5, 0.5}, false},
+		{"снаружи по Y", mgl32.Vec3{0.5, -0.01, 0.5}, false},
+		{"снаружи по Z", mgl32.Vec3{0.5, 0.5, 2}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitBox.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%v) = %v, ожидалось %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoxContainsBox(t *testing.T) {
+	tests := []struct {
+		name  string
+		other Box
+		want  bool
+	}{
+		{"сам себя", unitBox, true},
+		{"внутренний", NewBox(mgl32.Vec3{0.25, 0.25, 0.25}, mgl32.Vec3{0.75, 0.75, 0.75}), true},
+		{"выступает", NewBox(mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{1.5, 1, 1}), false},
+		{"снаружи", NewBox(mgl32.Vec3{2, 2, 2}, mgl32.Vec3{3, 3, 3}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitBox.ContainsBox(tt.other); got != tt.want {
+				t.Errorf("ContainsBox(%v) = %v, ожидалось %v", tt.other, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoxOverlaps(t *testing.T) {
+	tests := []struct {
+		name  string
+		other Box
+		want  bool
+	}{
+		{"пересекает", NewBox(mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{2, 2, 2}), true},
+		{"внутри", NewBox(mgl32.Vec3{0.25, 0.25, 0.25}, mgl32.Vec3{0.75, 0.75, 0.75}), true},
+		{"касается гранью", NewBox(mgl32.Vec3{1, 0, 0}, mgl32.Vec3{2, 1, 1}), false},
+		{"касается ребром", NewBox(mgl32.Vec3{1, 1, 0}, mgl32.Vec3{2, 2, 1}), false},
+		{"раздельно", NewBox(mgl32.Vec3{0, 3, 0}, mgl32.Vec3{1, 4, 1}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitBox.Overlaps(tt.other); got != tt.want {
+				t.Errorf("Overlaps(%v) = %v, ожидалось %v", tt.other, got, tt.want)
+			}
+			if got := tt.other.Overlaps(unitBox); got != tt.want {
+				t.Errorf("Overlaps не симметричен для %v", tt.other)
+			}
+		})
+	}
+}
+
+func TestBoxUnionIntersect(t *testing.T) {
+	tests := []struct {
+		name      string
+		other     Box
+		union     Box
+		intersect Box
+		empty     bool
+	}{
+		{
+			name:      "пересекающиеся",
+			other:     NewBox(mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{2, 2, 2}),
+			union:     NewBox(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{2, 2, 2}),
+			intersect: NewBox(mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{1, 1, 1}),
+		},
+		{
+			name:      "раздельные",
+			other:     NewBox(mgl32.Vec3{2, -1, 0}, mgl32.Vec3{3, 0, 1}),
+			union:     NewBox(mgl32.Vec3{0, -1, 0}, mgl32.Vec3{3, 1, 1}),
+			intersect: NewBox(mgl32.Vec3{2, 0, 0}, mgl32.Vec3{1, 0, 1}),
+			empty:     true,
+		},
+		{
+			name:      "вложенный",
+			other:     NewBox(mgl32.Vec3{0.25, 0.25, 0.25}, mgl32.Vec3{0.75, 0.75, 0.75}),
+			union:     unitBox,
+			intersect: NewBox(mgl32.Vec3{0.25, 0.25, 0.25}, mgl32.Vec3{0.75, 0.75, 0.75}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitBox.Union(tt.other); got != tt.union {
+				t.Errorf("Union() = %v, ожидалось %v", got, tt.union)
+			}
+			got := unitBox.Intersect(tt.other)
+			if got.IsEmpty() != tt.empty {
+				t.Errorf("Intersect().IsEmpty() = %v, ожидалось %v", got.IsEmpty(), tt.empty)
+			}
+			if !tt.empty && got != tt.intersect {
+				t.Errorf("Intersect() = %v, ожидалось %v", got, tt.intersect)
+			}
+		})
+	}
+}
+
+func TestBoxExpandTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Box
+		want Box
+	}{
+		{"Expand", unitBox.Expand(mgl32.Vec3{1, 0, 0.5}), NewBox(mgl32.Vec3{-1, 0, -0.5}, mgl32.Vec3{2, 1, 1.5})},
+		{"Expand сжатие", unitBox.Expand(mgl32.Vec3{-0.25, -0.25, -0.25}), NewBox(mgl32.Vec3{0.25, 0.25, 0.25}, mgl32.Vec3{0.75, 0.75, 0.75})},
+		{"Grow", unitBox.Grow(1), NewBox(mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{2, 2, 2})},
+		{"Translate", unitBox.Translate(mgl32.Vec3{1, -2, 3}), NewBox(mgl32.Vec3{1, -2, 3}, mgl32.Vec3{2, -1, 4})},
+		{"Stretch вниз", unitBox.Stretch(mgl32.Vec3{0, -2, 0.5}), NewBox(mgl32.Vec3{0, -2, 0}, mgl32.Vec3{1, 1, 1.5})},
+		{"BoxAround", BoxAround(mgl32.Vec3{1, 1, 1}, mgl32.Vec3{0.5, 1, 0.5}), NewBox(mgl32.Vec3{0.5, 0, 0.5}, mgl32.Vec3{1.5, 2, 1.5})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !approxVec(tt.got.Min, tt.want.Min) || !approxVec(tt.got.Max, tt.want.Max) {
+				t.Errorf("получено %v, ожидалось %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoxClosestPointDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		point    mgl32.Vec3
+		closest  mgl32.Vec3
+		distance float32
+	}{
+		{"внутри", mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{0.5, 0.5, 0.5}, 0},
+		{"напротив грани", mgl32.Vec3{3, 0.5, 0.5}, mgl32.Vec3{1, 0.5, 0.5}, 2},
+		{"напротив ребра", mgl32.Vec3{-3, -4, 0.5}, mgl32.Vec3{0, 0, 0.5}, 5},
+		{"напротив угла", mgl32.Vec3{2, 2, 2}, mgl32.Vec3{1, 1, 1}, sqrtf(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitBox.ClosestPoint(tt.point); !approxVec(got, tt.closest) {
+				t.Errorf("ClosestPoint(%v) = %v, ожидалось %v", tt.point, got, tt.closest)
+			}
+			if got := unitBox.Distance(tt.point); !approx(got, tt.distance) {
+				t.Errorf("Distance(%v) = %v, ожидалось %v", tt.point, got, tt.distance)
+			}
+		})
+	}
+}
+
+func TestBoxClipAxis(t *testing.T) {
+	tests := []struct {
+		name   string
+		moving Box
+		axis   int
+		offset float32
+		want   float32
+	}{
+		{"падение на бокс", NewBox(mgl32.Vec3{0, 2, 0}, mgl32.Vec3{1, 3, 1}), 1, -5, -1},
+		{"движение от бокса", NewBox(mgl32.Vec3{0, 2, 0}, mgl32.Vec3{1, 3, 1}), 1, 5, 5},
+		{"не достает", NewBox(mgl32.Vec3{0, 2, 0}, mgl32.Vec3{1, 3, 1}), 1, -0.5, -0.5},
+		{"мимо по X", NewBox(mgl32.Vec3{1, 2, 0}, mgl32.Vec3{2, 3, 1}), 1, -5, -5},
+		{"стена справа", NewBox(mgl32.Vec3{-2, 0, 0}, mgl32.Vec3{-1, 1, 1}), 0, 3, 1},
+		{"уже пересекает", NewBox(mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{1.5, 1.5, 1.5}), 0, -1, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unitBox.ClipAxis(tt.moving, tt.axis, tt.offset); !approx(got, tt.want) {
+				t.Errorf("ClipAxis() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package geometry
+
+// minf возвращает минимальное из двух чисел
+func minf(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxf возвращает максимальное из двух чисел
+func maxf(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// signF возвращает знак числа как множитель
+func signF(x float32) float32 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
+
+// clampf ограничивает число диапазоном [lo, hi]
+func clampf(x, lo, hi float32) float32 {
+	return minf(maxf(x, lo), hi)
+}
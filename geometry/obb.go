@@ -0,0 +1,239 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Доля, на которую проникновение по оси-ребру должно быть меньше, чем по оси-грани,
+// чтобы быть выбранным: при равенстве грани дают более устойчивую нормаль
+const edgeAxisBias = 0.95
+
+// OrientedBox представляет повернутый бокс (OBB) с центром, половинными размерами и ориентацией
+type OrientedBox struct {
+	Center      mgl32.Vec3
+	HalfExtents mgl32.Vec3
+	Orientation mgl32.Quat
+}
+
+// NewOrientedBox создает повернутый бокс
+func NewOrientedBox(center, halfExtents mgl32.Vec3, orientation mgl32.Quat) OrientedBox {
+	return OrientedBox{
+		Center:      center,
+		HalfExtents: halfExtents,
+		Orientation: orientation,
+	}
+}
+
+// OrientedBoxFromBox создает неповернутый OBB, совпадающий с AABB
+func OrientedBoxFromBox(b Box) OrientedBox {
+	return OrientedBox{
+		Center:      b.Center(),
+		HalfExtents: b.Size().Mul(0.5),
+		Orientation: mgl32.QuatIdent(),
+	}
+}
+
+// Axes возвращает оси бокса (единичные векторы локальных осей X, Y, Z в мировых координатах)
+func (o OrientedBox) Axes() [3]mgl32.Vec3 {
+	q := o.Orientation.Normalize()
+	return [3]mgl32.Vec3{
+		q.Rotate(mgl32.Vec3{1, 0, 0}),
+		q.Rotate(mgl32.Vec3{0, 1, 0}),
+		q.Rotate(mgl32.Vec3{0, 0, 1}),
+	}
+}
+
+// Corners возвращает восемь вершин бокса
+func (o OrientedBox) Corners() [8]mgl32.Vec3 {
+	axes := o.Axes()
+	var corners [8]mgl32.Vec3
+	for i := range corners {
+		corner := o.Center
+		for axis := 0; axis < 3; axis++ {
+			extent := o.HalfExtents[axis]
+			if i&(1<<axis) == 0 {
+				extent = -extent
+			}
+			corner = corner.Add(axes[axis].Mul(extent))
+		}
+		corners[i] = corner
+	}
+	return corners
+}
+
+// Bounds возвращает AABB, описанный вокруг бокса
+func (o OrientedBox) Bounds() Box {
+	axes := o.Axes()
+	var half mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		half[i] = o.projectedRadius(axes, unitAxis(i))
+	}
+	return BoxAround(o.Center, half)
+}
+
+// Contains проверяет, лежит ли точка внутри бокса или на его поверхности
+func (o OrientedBox) Contains(p mgl32.Vec3) bool {
+	offset := p.Sub(o.Center)
+	for axis, direction := range o.Axes() {
+		if absf(offset.Dot(direction)) > o.HalfExtents[axis]+Epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// Penetration проверяет пересечение двух боксов по теореме о разделяющей оси.
+// Возвращает нормаль, вдоль которой нужно сдвинуть other, чтобы развести боксы, и глубину проникновения.
+// ok равен false, если боксы не пересекаются (касание не считается пересечением).
+func (o OrientedBox) Penetration(other OrientedBox) (normal mgl32.Vec3, depth float32, ok bool) {
+	axesA, axesB := o.Axes(), other.Axes()
+	offset := other.Center.Sub(o.Center)
+
+	candidates := make([]mgl32.Vec3, 0, 15)
+	candidates = append(candidates, axesA[:]...)
+	candidates = append(candidates, axesB[:]...)
+	faces := len(candidates)
+	for _, a := range axesA {
+		for _, b := range axesB {
+			// Параллельные ребра не дают новой оси
+			if axis := a.Cross(b); axis.Len() > Epsilon {
+				candidates = append(candidates, axis.Normalize())
+			}
+		}
+	}
+
+	depth = -1
+	for i, axis := range candidates {
+		distance := offset.Dot(axis)
+		overlap := o.projectedRadius(axesA, axis) + other.projectedRadius(axesB, axis) - absf(distance)
+		if overlap <= 0 {
+			return mgl32.Vec3{}, 0, false
+		}
+		if i >= faces && depth >= 0 && overlap >= depth*edgeAxisBias {
+			continue
+		}
+		if depth < 0 || overlap < depth {
+			depth = overlap
+			normal = axis.Mul(signF(distance))
+		}
+	}
+
+	return normal, depth, true
+}
+
+// ContactPoints возвращает точки контакта пересекающихся боксов для нормали, найденной Penetration.
+// Если нормаль - ось грани одного из боксов, грань другого бокса, обращенная к ней, обрезается по боковым
+// граням опорной, и остаются вершины, вошедшие под опорную грань. При контакте ребрами возвращаются
+// вершины каждого бокса внутри другого или центр пересечения описанных боксов.
+func (o OrientedBox) ContactPoints(other OrientedBox, normal mgl32.Vec3) []mgl32.Vec3 {
+	if points := o.clipFace(other, normal); len(points) > 0 {
+		return points
+	}
+	if points := other.clipFace(o, normal.Mul(-1)); len(points) > 0 {
+		return points
+	}
+
+	points := make([]mgl32.Vec3, 0, 8)
+	for _, corner := range o.Corners() {
+		if other.Contains(corner) {
+			points = append(points, corner)
+		}
+	}
+	for _, corner := range other.Corners() {
+		if o.Contains(corner) {
+			points = append(points, corner)
+		}
+	}
+	if len(points) == 0 {
+		points = append(points, o.Bounds().Intersect(other.Bounds()).Center())
+	}
+	return points
+}
+
+// clipFace обрезает грань other, обращенную к грани o с внешней нормалью normal, по боковым граням o
+// и возвращает вершины, лежащие не выше грани o. Возвращает nil, если normal не совпадает с осью o.
+func (o OrientedBox) clipFace(other OrientedBox, normal mgl32.Vec3) []mgl32.Vec3 {
+	axes := o.Axes()
+	reference := -1
+	for i, axis := range axes {
+		if absf(axis.Dot(normal)) >= 1-Epsilon {
+			reference = i
+		}
+	}
+	if reference < 0 {
+		return nil
+	}
+
+	polygon := other.face(normal.Mul(-1))
+	for i, axis := range axes {
+		if i == reference {
+			continue
+		}
+		offset := o.Center.Dot(axis)
+		polygon = clipPolygon(polygon, axis, offset+o.HalfExtents[i])
+		polygon = clipPolygon(polygon, axis.Mul(-1), o.HalfExtents[i]-offset)
+	}
+
+	surface := o.Center.Dot(normal) + o.HalfExtents[reference]
+	points := polygon[:0]
+	for _, point := range polygon {
+		if point.Dot(normal) <= surface+Epsilon {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// face возвращает вершины грани бокса, внешняя нормаль которой ближе всего к направлению, в порядке обхода
+func (o OrientedBox) face(direction mgl32.Vec3) []mgl32.Vec3 {
+	axes := o.Axes()
+	best, sign := 0, float32(1)
+	for i, axis := range axes {
+		if d := axis.Dot(direction); absf(d) > absf(axes[best].Dot(direction)) {
+			best = i
+		}
+	}
+	if axes[best].Dot(direction) < 0 {
+		sign = -1
+	}
+
+	u, v := axes[(best+1)%3], axes[(best+2)%3]
+	eu, ev := o.HalfExtents[(best+1)%3], o.HalfExtents[(best+2)%3]
+	center := o.Center.Add(axes[best].Mul(sign * o.HalfExtents[best]))
+	return []mgl32.Vec3{
+		center.Add(u.Mul(eu)).Add(v.Mul(ev)),
+		center.Sub(u.Mul(eu)).Add(v.Mul(ev)),
+		center.Sub(u.Mul(eu)).Sub(v.Mul(ev)),
+		center.Add(u.Mul(eu)).Sub(v.Mul(ev)),
+	}
+}
+
+// clipPolygon оставляет часть выпуклого многоугольника, для которой point·normal <= distance
+func clipPolygon(polygon []mgl32.Vec3, normal mgl32.Vec3, distance float32) []mgl32.Vec3 {
+	clipped := make([]mgl32.Vec3, 0, len(polygon)+1)
+	for i, current := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		dc, dn := current.Dot(normal)-distance, next.Dot(normal)-distance
+		if dc <= 0 {
+			clipped = append(clipped, current)
+		}
+		if (dc < 0 && dn > 0) || (dc > 0 && dn < 0) {
+			clipped = append(clipped, current.Add(next.Sub(current).Mul(dc/(dc-dn))))
+		}
+	}
+	return clipped
+}
+
+// projectedRadius возвращает половину длины проекции бокса на ось
+func (o OrientedBox) projectedRadius(axes [3]mgl32.Vec3, axis mgl32.Vec3) float32 {
+	return o.HalfExtents[0]*absf(axes[0].Dot(axis)) +
+		o.HalfExtents[1]*absf(axes[1].Dot(axis)) +
+		o.HalfExtents[2]*absf(axes[2].Dot(axis))
+}
+
+// unitAxis возвращает единичный вектор вдоль мировой оси
+func unitAxis(axis int) mgl32.Vec3 {
+	v := mgl32.Vec3{}
+	v[axis] = 1
+	return v
+}
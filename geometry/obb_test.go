@@ -0,0 +1,151 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// rotatedY повернутый вокруг вертикали на 45° бокс с центром в точке
+func rotatedY(center mgl32.Vec3) OrientedBox {
+	return NewOrientedBox(center, mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{0, 1, 0}))
+}
+
+func TestOrientedBoxBounds(t *testing.T) {
+	diagonal := float32(math.Sqrt2 / 2)
+	tests := []struct {
+		name string
+		box  OrientedBox
+		want Box
+	}{
+		{"без поворота", OrientedBoxFromBox(unitBox), unitBox},
+		{"поворот на 45°", rotatedY(mgl32.Vec3{0, 0, 0}),
+			NewBox(mgl32.Vec3{-diagonal, -0.5, -diagonal}, mgl32.Vec3{diagonal, 0.5, diagonal})},
+		{"поворот на 90°", NewOrientedBox(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 0.25, 0.5}, mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 0, 1})),
+			NewBox(mgl32.Vec3{-0.25, -1, -0.5}, mgl32.Vec3{0.25, 1, 0.5})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.box.Bounds()
+			if !approxVec(got.Min, tt.want.Min) || !approxVec(got.Max, tt.want.Max) {
+				t.Errorf("Bounds() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrientedBoxContains(t *testing.T) {
+	box := rotatedY(mgl32.Vec3{0, 0, 0})
+	tests := []struct {
+		name  string
+		point mgl32.Vec3
+		want  bool
+	}{
+		{"центр", mgl32.Vec3{0, 0, 0}, true},
+		{"вершина", mgl32.Vec3{float32(math.Sqrt2 / 2), 0.5, 0}, true},
+		// Угол описанного бокса лежит вне повернутого
+		{"угол описанного бокса", mgl32.Vec3{0.6, 0, 0.6}, false},
+		{"выше", mgl32.Vec3{0, 0.6, 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := box.Contains(tt.point); got != tt.want {
+				t.Errorf("Contains(%v) = %v, ожидалось %v", tt.point, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrientedBoxPenetration(t *testing.T) {
+	tests := []struct {
+		name   string
+		other  OrientedBox
+		ok     bool
+		normal mgl32.Vec3
+		depth  float32
+	}{
+		{"сбоку", OrientedBoxFromBox(unitBox.Translate(mgl32.Vec3{0.75, 0, 0})), true, mgl32.Vec3{1, 0, 0}, 0.25},
+		{"сверху", OrientedBoxFromBox(unitBox.Translate(mgl32.Vec3{0.1, 0.9, 0})), true, mgl32.Vec3{0, 1, 0}, 0.1},
+		{"касание", OrientedBoxFromBox(unitBox.Translate(mgl32.Vec3{1, 0, 0})), false, mgl32.Vec3{}, 0},
+		{"повернутый на грани", rotatedY(mgl32.Vec3{0.5, 1.4, 0.5}), true, mgl32.Vec3{0, 1, 0}, 0.1},
+		// Описанные боксы пересекаются, а повернутый бокс проходит мимо угла
+		{"мимо угла", rotatedY(mgl32.Vec3{1.95, 0.5, 1.95}), false, mgl32.Vec3{}, 0},
+		{"угол в угол", rotatedY(mgl32.Vec3{1.6, 0.5, 0.5}), true, mgl32.Vec3{1, 0, 0}, float32(math.Sqrt2/2) - 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normal, depth, ok := OrientedBoxFromBox(unitBox).Penetration(tt.other)
+			if ok != tt.ok {
+				t.Fatalf("Penetration() ok = %v, ожидалось %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if !approxVec(normal, tt.normal) || !approx(depth, tt.depth) {
+				t.Errorf("Penetration() = %v, %v, ожидалось %v, %v", normal, depth, tt.normal, tt.depth)
+			}
+		})
+	}
+}
+
+// crossedEdge куб, ребро которого скрещено с верхним правым ребром единичного бокса и входит в него на depth
+func crossedEdge(depth float32) OrientedBox {
+	h := float32(math.Sqrt2 / 2)
+	// Ребро куба направлено вдоль (-1, 1, 0), прилегающие к нему грани смотрят к единичному боксу
+	rotation := mgl32.Mat4ToQuat(mgl32.Mat3FromCols(mgl32.Vec3{-h, h, 0}, mgl32.Vec3{0.5, 0.5, h}, mgl32.Vec3{0.5, 0.5, -h}).Mat4())
+	center := mgl32.Vec3{1 + (h-depth)*h, 1 + (h-depth)*h, 0.5}
+	return NewOrientedBox(center, mgl32.Vec3{0.5, 0.5, 0.5}, rotation)
+}
+
+func TestOrientedBoxContactPoints(t *testing.T) {
+	diagonal := float32(math.Sqrt2 / 2)
+	tests := []struct {
+		name   string
+		other  OrientedBox
+		count  int
+		center mgl32.Vec3
+	}{
+		// Нижняя грань верхнего бокса целиком лежит на верхней грани нижнего
+		{"стопка", OrientedBoxFromBox(unitBox.Translate(mgl32.Vec3{0, 0.9, 0})), 4, mgl32.Vec3{0.5, 0.9, 0.5}},
+		// Грань повернутого бокса обрезается по граням единичного, вершины которого лежат снаружи
+		{"повернутый на грани", rotatedY(mgl32.Vec3{0.5, 1.4, 0.5}), 8, mgl32.Vec3{0.5, 0.9, 0.5}},
+		// Внутрь единичного бокса входит вертикальное ребро ромба
+		{"угол", rotatedY(mgl32.Vec3{1.6, 0.5, 0.5}), 2, mgl32.Vec3{1.6 - float32(math.Sqrt2/2), 0.5, 0.5}},
+		// Длинный брус лежит ребром поперек грани: ребро обрезается по краям грани
+		{"ребро поперек грани", NewOrientedBox(mgl32.Vec3{0.5, 0.95 + 0.1*float32(math.Sqrt2), 0.5}, mgl32.Vec3{1, 0.1, 0.1}, mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{1, 0, 0})),
+			2, mgl32.Vec3{0.5, 0.95, 0.5}},
+		// Нормаль - ось грани повернутого бокса: по ней обрезается грань единичного, и остается его ребро
+		{"ребро в грань повернутого", rotatedY(mgl32.Vec3{1 + 0.4*diagonal, 0.5, 1 + 0.4*diagonal}), 2, mgl32.Vec3{1, 0.5, 1}},
+		// Нормаль - ось-ребро: точкой контакта служит угол единичного бокса внутри наклонного куба
+		{"угол в наклонный куб", NewOrientedBox(mgl32.Vec3{-0.5, 1.45, 0.25}, mgl32.Vec3{0.5, 0.5, 0.5},
+			mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{1, 0, 0}).Mul(mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{0, 1, 0}))),
+			1, mgl32.Vec3{0, 1, 0}},
+		// Ребра скрещены, и ни одна вершина не входит в другой бокс: остается центр пересечения описанных боксов
+		{"ребро к ребру", crossedEdge(0.1), 1, mgl32.Vec3{1 - 0.3*diagonal, 1 - 0.3*diagonal, 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := OrientedBoxFromBox(unitBox)
+			normal, _, ok := box.Penetration(tt.other)
+			if !ok {
+				t.Fatal("Penetration() не нашел пересечения")
+			}
+			points := box.ContactPoints(tt.other, normal)
+			if len(points) != tt.count {
+				t.Fatalf("ContactPoints() вернул %d точек, ожидалось %d", len(points), tt.count)
+			}
+			center := mgl32.Vec3{}
+			for _, point := range points {
+				center = center.Add(point.Mul(1 / float32(len(points))))
+			}
+			if !approxVec(center, tt.center) {
+				t.Errorf("центр точек контакта = %v, ожидалось %v", center, tt.center)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Plane представляет плоскость Normal·p + D = 0 с единичной нормалью
+type Plane struct {
+	Normal mgl32.Vec3
+	D      float32
+}
+
+// NewPlane создает плоскость с заданной нормалью, проходящую через точку
+func NewPlane(normal, point mgl32.Vec3) Plane {
+	if normal.Len() > 0 {
+		normal = normal.Normalize()
+	}
+	return Plane{Normal: normal, D: -normal.Dot(point)}
+}
+
+// SignedDistance возвращает расстояние от точки до плоскости со знаком:
+// положительное со стороны нормали, отрицательное с обратной стороны
+func (p Plane) SignedDistance(point mgl32.Vec3) float32 {
+	return p.Normal.Dot(point) + p.D
+}
+
+// Project возвращает проекцию точки на плоскость
+func (p Plane) Project(point mgl32.Vec3) mgl32.Vec3 {
+	return point.Sub(p.Normal.Mul(p.SignedDistance(point)))
+}
+
+// ClassifyBox определяет положение бокса относительно плоскости:
+// 1 - целиком со стороны нормали, -1 - целиком с обратной стороны, 0 - пересекает плоскость
+func (p Plane) ClassifyBox(b Box) int {
+	center := b.Center()
+	half := b.Size().Mul(0.5)
+	// Проекция половинных размеров бокса на нормаль
+	radius := half.X()*absf(p.Normal.X()) + half.Y()*absf(p.Normal.Y()) + half.Z()*absf(p.Normal.Z())
+	distance := p.SignedDistance(center)
+
+	switch {
+	case distance > radius:
+		return 1
+	case distance < -radius:
+		return -1
+	}
+	return 0
+}
+
+// absf возвращает модуль числа
+func absf(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,114 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Ray представляет луч с началом и направлением.
+// Параметр t точек луча измеряется в длинах Direction; для единичного направления это расстояние.
+type Ray struct {
+	Origin    mgl32.Vec3
+	Direction mgl32.Vec3
+}
+
+// NewRay создает луч с нормализованным направлением
+func NewRay(origin, direction mgl32.Vec3) Ray {
+	if direction.Len() > 0 {
+		direction = direction.Normalize()
+	}
+	return Ray{Origin: origin, Direction: direction}
+}
+
+// At возвращает точку луча с параметром t
+func (r Ray) At(t float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Direction.Mul(t))
+}
+
+// IntersectBox проверяет пересечение луча с боксом методом пластин.
+// Возвращает параметр точки входа и нормаль грани входа (нулевую, если начало внутри бокса).
+func (r Ray) IntersectBox(b Box) (float32, mgl32.Vec3, bool) {
+	return b.IntersectRay(r.Origin, r.Direction)
+}
+
+// IntersectSphere возвращает параметр первой точки пересечения луча со сферой.
+// Если начало луча внутри сферы, возвращает t = 0.
+func (r Ray) IntersectSphere(s Sphere) (float32, bool) {
+	a := r.Direction.Dot(r.Direction)
+	if a == 0 {
+		return 0, s.Contains(r.Origin)
+	}
+
+	offset := r.Origin.Sub(s.Center)
+	b := offset.Dot(r.Direction)
+	c := offset.Dot(offset) - s.Radius*s.Radius
+	if c <= 0 {
+		return 0, true
+	}
+
+	discriminant := b*b - a*c
+	if discriminant < 0 || b > 0 {
+		return 0, false
+	}
+	return (-b - sqrtf(discriminant)) / a, true
+}
+
+// IntersectPlane возвращает параметр точки пересечения луча с плоскостью.
+// Луч, параллельный плоскости или направленный от нее, не пересекает ее.
+func (r Ray) IntersectPlane(p Plane) (float32, bool) {
+	denom := p.Normal.Dot(r.Direction)
+	if denom == 0 {
+		return 0, false
+	}
+	t := -p.SignedDistance(r.Origin) / denom
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// Segment представляет отрезок между двумя точками
+type Segment struct {
+	Start mgl32.Vec3
+	End   mgl32.Vec3
+}
+
+// Delta возвращает вектор от начала отрезка к концу
+func (s Segment) Delta() mgl32.Vec3 {
+	return s.End.Sub(s.Start)
+}
+
+// Length возвращает длину отрезка
+func (s Segment) Length() float32 {
+	return s.Delta().Len()
+}
+
+// At возвращает точку отрезка с параметром t (0 - начало, 1 - конец)
+func (s Segment) At(t float32) mgl32.Vec3 {
+	return s.Start.Add(s.Delta().Mul(t))
+}
+
+// Bounds возвращает бокс, ограничивающий отрезок
+func (s Segment) Bounds() Box {
+	return Box{Min: s.Start, Max: s.Start}.Union(Box{Min: s.End, Max: s.End})
+}
+
+// ClosestPoint возвращает точку отрезка, ближайшую к заданной
+func (s Segment) ClosestPoint(p mgl32.Vec3) mgl32.Vec3 {
+	delta := s.Delta()
+	lengthSq := delta.Dot(delta)
+	if lengthSq == 0 {
+		return s.Start
+	}
+	t := clampf(p.Sub(s.Start).Dot(delta)/lengthSq, 0, 1)
+	return s.At(t)
+}
+
+// IntersectBox проверяет пересечение отрезка с боксом.
+// Возвращает параметр точки входа (0..1) и нормаль грани входа.
+func (s Segment) IntersectBox(b Box) (float32, mgl32.Vec3, bool) {
+	t, normal, ok := b.IntersectRay(s.Start, s.Delta())
+	if !ok || t > 1 {
+		return 0, mgl32.Vec3{}, false
+	}
+	return t, normal, true
+}
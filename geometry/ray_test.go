@@ -0,0 +1,151 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestRayIntersectBox(t *testing.T) {
+	tests := []struct {
+		name   string
+		ray    Ray
+		hit    bool
+		t      float32
+		normal mgl32.Vec3
+	}{
+		{"в грань -X", NewRay(mgl32.Vec3{-2, 0.5, 0.5}, mgl32.Vec3{1, 0, 0}), true, 2, mgl32.Vec3{-1, 0, 0}},
+		{"сверху", NewRay(mgl32.Vec3{0.5, 5, 0.5}, mgl32.Vec3{0, -1, 0}), true, 4, mgl32.Vec3{0, 1, 0}},
+		{"по диагонали", NewRay(mgl32.Vec3{-1, -1, 0.5}, mgl32.Vec3{1, 1, 0}), true, sqrtf(2), mgl32.Vec3{-1, 0, 0}},
+		{"изнутри", NewRay(mgl32.Vec3{0.5, 0.5, 0.5}, mgl32.Vec3{0, 0, 1}), true, 0, mgl32.Vec3{}},
+		{"мимо", NewRay(mgl32.Vec3{-2, 2, 0.5}, mgl32.Vec3{1, 0, 0}), false, 0, mgl32.Vec3{}},
+		{"от бокса", NewRay(mgl32.Vec3{-2, 0.5, 0.5}, mgl32.Vec3{-1, 0, 0}), false, 0, mgl32.Vec3{}},
+		{"параллельно снаружи", NewRay(mgl32.Vec3{0.5, 1.5, -3}, mgl32.Vec3{0, 0, 1}), false, 0, mgl32.Vec3{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, normal, ok := tt.ray.IntersectBox(unitBox)
+			if ok != tt.hit {
+				t.Fatalf("IntersectBox() ok = %v, ожидалось %v", ok, tt.hit)
+			}
+			if !ok {
+				return
+			}
+			if !approx(got, tt.t) {
+				t.Errorf("t = %v, ожидалось %v", got, tt.t)
+			}
+			if !approxVec(normal, tt.normal) {
+				t.Errorf("normal = %v, ожидалось %v", normal, tt.normal)
+			}
+		})
+	}
+}
+
+func TestRayIntersectSphere(t *testing.T) {
+	sphere := Sphere{Center: mgl32.Vec3{0, 0, 0}, Radius: 1}
+	tests := []struct {
+		name string
+		ray  Ray
+		hit  bool
+		t    float32
+	}{
+		{"в центр", NewRay(mgl32.Vec3{-5, 0, 0}, mgl32.Vec3{1, 0, 0}), true, 4},
+		{"по касательной", NewRay(mgl32.Vec3{-5, 1, 0}, mgl32.Vec3{1, 0, 0}), true, 5},
+		{"мимо", NewRay(mgl32.Vec3{-5, 1.5, 0}, mgl32.Vec3{1, 0, 0}), false, 0},
+		{"от сферы", NewRay(mgl32.Vec3{-5, 0, 0}, mgl32.Vec3{-1, 0, 0}), false, 0},
+		{"изнутри", NewRay(mgl32.Vec3{0.5, 0, 0}, mgl32.Vec3{1, 0, 0}), true, 0},
+		{"ненормированное направление", Ray{Origin: mgl32.Vec3{0, -5, 0}, Direction: mgl32.Vec3{0, 2, 0}}, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.ray.IntersectSphere(sphere)
+			if ok != tt.hit {
+				t.Fatalf("IntersectSphere() ok = %v, ожидалось %v", ok, tt.hit)
+			}
+			if ok && !approx(got, tt.t) {
+				t.Errorf("t = %v, ожидалось %v", got, tt.t)
+			}
+		})
+	}
+}
+
+func TestRayIntersectPlane(t *testing.T) {
+	ground := NewPlane(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 2, 0})
+	tests := []struct {
+		name string
+		ray  Ray
+		hit  bool
+		t    float32
+	}{
+		{"сверху вниз", NewRay(mgl32.Vec3{3, 7, 1}, mgl32.Vec3{0, -1, 0}), true, 5},
+		{"под углом", NewRay(mgl32.Vec3{0, 3, 0}, mgl32.Vec3{1, -1, 0}), true, sqrtf(2)},
+		{"снизу вверх", NewRay(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 1, 0}), true, 2},
+		{"параллельно", NewRay(mgl32.Vec3{0, 3, 0}, mgl32.Vec3{1, 0, 0}), false, 0},
+		{"от плоскости", NewRay(mgl32.Vec3{0, 3, 0}, mgl32.Vec3{0, 1, 0}), false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.ray.IntersectPlane(ground)
+			if ok != tt.hit {
+				t.Fatalf("IntersectPlane() ok = %v, ожидалось %v", ok, tt.hit)
+			}
+			if ok && !approx(got, tt.t) {
+				t.Errorf("t = %v, ожидалось %v", got, tt.t)
+			}
+		})
+	}
+}
+
+func TestSegment(t *testing.T) {
+	segment := Segment{Start: mgl32.Vec3{-2, 0.5, 0.5}, End: mgl32.Vec3{2, 0.5, 0.5}}
+
+	closest := []struct {
+		name  string
+		point mgl32.Vec3
+		want  mgl32.Vec3
+	}{
+		{"над серединой", mgl32.Vec3{0, 3, 0.5}, mgl32.Vec3{0, 0.5, 0.5}},
+		{"за началом", mgl32.Vec3{-5, 0, 0}, mgl32.Vec3{-2, 0.5, 0.5}},
+		{"за концом", mgl32.Vec3{9, 1, 1}, mgl32.Vec3{2, 0.5, 0.5}},
+	}
+	for _, tt := range closest {
+		t.Run("ClosestPoint "+tt.name, func(t *testing.T) {
+			if got := segment.ClosestPoint(tt.point); !approxVec(got, tt.want) {
+				t.Errorf("ClosestPoint(%v) = %v, ожидалось %v", tt.point, got, tt.want)
+			}
+		})
+	}
+
+	intersects := []struct {
+		name    string
+		segment Segment
+		hit     bool
+		t       float32
+	}{
+		{"пересекает", segment, true, 0.5},
+		{"не достает", Segment{Start: mgl32.Vec3{-3, 0.5, 0.5}, End: mgl32.Vec3{-1, 0.5, 0.5}}, false, 0},
+		{"заканчивается на грани", Segment{Start: mgl32.Vec3{-1, 0.5, 0.5}, End: mgl32.Vec3{0, 0.5, 0.5}}, true, 1},
+		{"мимо", Segment{Start: mgl32.Vec3{-1, 2, 0.5}, End: mgl32.Vec3{2, 2, 0.5}}, false, 0},
+	}
+	for _, tt := range intersects {
+		t.Run("IntersectBox "+tt.name, func(t *testing.T) {
+			got, _, ok := tt.segment.IntersectBox(unitBox)
+			if ok != tt.hit {
+				t.Fatalf("IntersectBox() ok = %v, ожидалось %v", ok, tt.hit)
+			}
+			if ok && !approx(got, tt.t) {
+				t.Errorf("t = %v, ожидалось %v", got, tt.t)
+			}
+		})
+	}
+
+	if got := segment.Length(); !approx(got, 4) {
+		t.Errorf("Length() = %v, ожидалось 4", got)
+	}
+	if got := segment.Bounds(); got != NewBox(mgl32.Vec3{-2, 0.5, 0.5}, mgl32.Vec3{2, 0.5, 0.5}) {
+		t.Errorf("Bounds() = %v", got)
+	}
+}
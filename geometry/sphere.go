@@ -0,0 +1,41 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Sphere представляет сферу с центром и радиусом
+type Sphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+// Contains проверяет, лежит ли точка внутри сферы или на ее поверхности
+func (s Sphere) Contains(p mgl32.Vec3) bool {
+	offset := p.Sub(s.Center)
+	return offset.Dot(offset) <= s.Radius*s.Radius
+}
+
+// Bounds возвращает бокс, описанный вокруг сферы
+func (s Sphere) Bounds() Box {
+	return BoxAround(s.Center, mgl32.Vec3{s.Radius, s.Radius, s.Radius})
+}
+
+// OverlapsBox проверяет пересечение сферы с боксом (касание считается пересечением)
+func (s Sphere) OverlapsBox(b Box) bool {
+	return s.Contains(b.ClosestPoint(s.Center))
+}
+
+// OverlapsSphere проверяет пересечение двух сфер (касание считается пересечением)
+func (s Sphere) OverlapsSphere(other Sphere) bool {
+	radius := s.Radius + other.Radius
+	offset := other.Center.Sub(s.Center)
+	return offset.Dot(offset) <= radius*radius
+}
+
+// sqrtf вычисляет квадратный корень числа float32
+func sqrtf(x float32) float32 {
+	return float32(math.Sqrt(float64(x)))
+}
@@ -0,0 +1,100 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSphereOverlapsBox(t *testing.T) {
+	tests := []struct {
+		name   string
+		sphere Sphere
+		want   bool
+	}{
+		{"центр внутри", Sphere{Center: mgl32.Vec3{0.5, 0.5, 0.5}, Radius: 0.1}, true},
+		{"у грани", Sphere{Center: mgl32.Vec3{1.5, 0.5, 0.5}, Radius: 0.6}, true},
+		{"касается грани", Sphere{Center: mgl32.Vec3{1.5, 0.5, 0.5}, Radius: 0.5}, true},
+		{"не достает до грани", Sphere{Center: mgl32.Vec3{1.5, 0.5, 0.5}, Radius: 0.4}, false},
+		// До угла sqrt(3)*0.5 ≈ 0.866: описанный бокс сферы пересекает бокс, а сама сфера нет
+		{"у угла мимо", Sphere{Center: mgl32.Vec3{1.5, 1.5, 1.5}, Radius: 0.8}, false},
+		{"у угла", Sphere{Center: mgl32.Vec3{1.5, 1.5, 1.5}, Radius: 0.9}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sphere.OverlapsBox(unitBox); got != tt.want {
+				t.Errorf("OverlapsBox() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSphereOverlapsSphere(t *testing.T) {
+	a := Sphere{Center: mgl32.Vec3{0, 0, 0}, Radius: 1}
+	tests := []struct {
+		name string
+		b    Sphere
+		want bool
+	}{
+		{"пересекаются", Sphere{Center: mgl32.Vec3{1.5, 0, 0}, Radius: 1}, true},
+		{"касаются", Sphere{Center: mgl32.Vec3{0, 2, 0}, Radius: 1}, true},
+		{"раздельно", Sphere{Center: mgl32.Vec3{0, 0, 3}, Radius: 1}, false},
+		{"вложенная", Sphere{Center: mgl32.Vec3{0.1, 0, 0}, Radius: 0.2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.OverlapsSphere(tt.b); got != tt.want {
+				t.Errorf("OverlapsSphere() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlane(t *testing.T) {
+	plane := NewPlane(mgl32.Vec3{0, 2, 0}, mgl32.Vec3{0, 1, 0})
+	tests := []struct {
+		name     string
+		point    mgl32.Vec3
+		distance float32
+		project  mgl32.Vec3
+	}{
+		{"над плоскостью", mgl32.Vec3{3, 4, 5}, 3, mgl32.Vec3{3, 1, 5}},
+		{"под плоскостью", mgl32.Vec3{0, -1, 0}, -2, mgl32.Vec3{0, 1, 0}},
+		{"на плоскости", mgl32.Vec3{7, 1, -7}, 0, mgl32.Vec3{7, 1, -7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plane.SignedDistance(tt.point); !approx(got, tt.distance) {
+				t.Errorf("SignedDistance() = %v, ожидалось %v", got, tt.distance)
+			}
+			if got := plane.Project(tt.point); !approxVec(got, tt.project) {
+				t.Errorf("Project() = %v, ожидалось %v", got, tt.project)
+			}
+		})
+	}
+}
+
+func TestPlaneClassifyBox(t *testing.T) {
+	tests := []struct {
+		name  string
+		plane Plane
+		want  int
+	}{
+		{"бокс сверху", NewPlane(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, -1, 0}), 1},
+		{"бокс снизу", NewPlane(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 2, 0}), -1},
+		{"пересекает", NewPlane(mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0.5, 0}), 0},
+		{"наклонная через угол", NewPlane(mgl32.Vec3{1, 1, 1}, mgl32.Vec3{1, 1, 1}.Mul(0.9)), 0},
+		{"наклонная за углом", NewPlane(mgl32.Vec3{1, 1, 1}, mgl32.Vec3{1.1, 1.1, 1.1}), -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.plane.ClassifyBox(unitBox); got != tt.want {
+				t.Errorf("ClassifyBox() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
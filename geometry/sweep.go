@@ -0,0 +1,26 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SweepBox находит момент первого касания бокса moving, смещающегося на movement, с боксом target.
+// Возвращает долю смещения до касания (0..1) и нормаль грани target в точке касания.
+// Если боксы уже пересекаются, возвращает 0 и нулевую нормаль.
+func SweepBox(moving Box, movement mgl32.Vec3, target Box) (float32, mgl32.Vec3, bool) {
+	// Сумма Минковского: центр движущегося бокса движется по отрезку против расширенной цели
+	expanded := target.Expand(moving.Size().Mul(0.5))
+	center := moving.Center()
+	return Segment{Start: center, End: center.Add(movement)}.IntersectBox(expanded)
+}
+
+// SweepSphere находит момент первого касания сферы, смещающейся на movement, с неподвижной сферой target.
+// Возвращает долю смещения до касания (0..1).
+func SweepSphere(moving Sphere, movement mgl32.Vec3, target Sphere) (float32, bool) {
+	expanded := Sphere{Center: target.Center, Radius: target.Radius + moving.Radius}
+	t, ok := Ray{Origin: moving.Center, Direction: movement}.IntersectSphere(expanded)
+	if !ok || t > 1 {
+		return 0, false
+	}
+	return t, true
+}
@@ -0,0 +1,72 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSweepBox(t *testing.T) {
+	moving := NewBox(mgl32.Vec3{-3, 0, 0}, mgl32.Vec3{-2, 1, 1})
+	tests := []struct {
+		name     string
+		moving   Box
+		movement mgl32.Vec3
+		hit      bool
+		t        float32
+		normal   mgl32.Vec3
+	}{
+		{"упирается в грань", moving, mgl32.Vec3{4, 0, 0}, true, 0.5, mgl32.Vec3{-1, 0, 0}},
+		{"не достает", moving, mgl32.Vec3{1, 0, 0}, false, 0, mgl32.Vec3{}},
+		{"проходит над", moving.Translate(mgl32.Vec3{0, 1.5, 0}), mgl32.Vec3{4, 0, 0}, false, 0, mgl32.Vec3{}},
+		{"задевает краем", moving.Translate(mgl32.Vec3{0, 0.9, 0}), mgl32.Vec3{4, 0, 0}, true, 0.5, mgl32.Vec3{-1, 0, 0}},
+		{"падает сверху", NewBox(mgl32.Vec3{0, 3, 0}, mgl32.Vec3{1, 4, 1}), mgl32.Vec3{0, -10, 0}, true, 0.2, mgl32.Vec3{0, 1, 0}},
+		{"уже пересекает", unitBox.Translate(mgl32.Vec3{0.5, 0, 0}), mgl32.Vec3{1, 0, 0}, true, 0, mgl32.Vec3{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, normal, ok := SweepBox(tt.moving, tt.movement, unitBox)
+			if ok != tt.hit {
+				t.Fatalf("SweepBox() ok = %v, ожидалось %v", ok, tt.hit)
+			}
+			if !ok {
+				return
+			}
+			if !approx(got, tt.t) {
+				t.Errorf("t = %v, ожидалось %v", got, tt.t)
+			}
+			if !approxVec(normal, tt.normal) {
+				t.Errorf("normal = %v, ожидалось %v", normal, tt.normal)
+			}
+		})
+	}
+}
+
+func TestSweepSphere(t *testing.T) {
+	target := Sphere{Center: mgl32.Vec3{0, 0, 0}, Radius: 1}
+	tests := []struct {
+		name     string
+		moving   Sphere
+		movement mgl32.Vec3
+		hit      bool
+		t        float32
+	}{
+		{"лобовое", Sphere{Center: mgl32.Vec3{-5, 0, 0}, Radius: 1}, mgl32.Vec3{6, 0, 0}, true, 0.5},
+		{"не достает", Sphere{Center: mgl32.Vec3{-5, 0, 0}, Radius: 1}, mgl32.Vec3{2, 0, 0}, false, 0},
+		{"мимо", Sphere{Center: mgl32.Vec3{-5, 3, 0}, Radius: 1}, mgl32.Vec3{10, 0, 0}, false, 0},
+		{"уже пересекает", Sphere{Center: mgl32.Vec3{1, 0, 0}, Radius: 0.5}, mgl32.Vec3{1, 0, 0}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SweepSphere(tt.moving, tt.movement, target)
+			if ok != tt.hit {
+				t.Fatalf("SweepSphere() ok = %v, ожидалось %v", ok, tt.hit)
+			}
+			if ok && !approx(got, tt.t) {
+				t.Errorf("t = %v, ожидалось %v", got, tt.t)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// resolveBodyCollisions находит пересекающиеся пары тел через пространственный хеш
+// и разводит их с учетом масс.
+func (p *PhysicsEngine) resolveBodyCollisions(bodies []*RigidBody) {
+	p.broadphase.Clear()
+	index := indexOf(bodies)
+	for _, body := range bodies {
+		p.broadphase.Insert(body)
+	}
+
+	// Каждая пара учитывается один раз: второе тело всегда зарегистрировано позже первого
+	p.pairs = p.pairs[:0]
+	for i, a := range bodies {
+		for _, b := range p.broadphase.Query(*a.Collider) {
+			if index[b] <= i {
+				continue
+			}
+			p.pairs = append(p.pairs, BodyPair{A: a, B: b})
+		}
+	}
+
+	// Тело, вошедшее в спящее, будит его до разбиения на острова
+	for _, pair := range p.pairs {
+		p.wakeByContact(pair)
+	}
+
+	// Пары разных островов не имеют общих изменяемых тел, поэтому острова разрешаются параллельно.
+	// Внутри острова пары обрабатываются в исходном порядке
+	indices := pairIndices(p.pairs, index)
+	_, island := islands(bodies, indices)
+	islandPairs := make(map[int][]int)
+	order := make([]int, 0)
+	hits := make([]eventBuffer, len(p.pairs))
+	for j, pair := range indices {
+		k := island[pair[0]]
+		if k < 0 {
+			k = island[pair[1]]
+		}
+		if k < 0 {
+			// Кинематические тела не расталкивают друг друга, а только сообщают о контакте
+			p.detectBodyHit(p.pairs[j], &hits[j])
+			continue
+		}
+		if _, ok := islandPairs[k]; !ok {
+			order = append(order, k)
+		}
+		islandPairs[k] = append(islandPairs[k], j)
+	}
+	p.parallel(len(order), func(n int) {
+		for _, j := range islandPairs[order[n]] {
+			pair := p.pairs[j]
+			p.detectBodyHit(pair, &hits[j])
+			// Спящие тела уже разведены
+			if !pair.A.Sleeping || !pair.B.Sleeping {
+				p.separate(pair.A, pair.B)
+			}
+		}
+	})
+
+	touching := make(map[BodyPair]bool, len(p.pairs))
+	for j, pair := range p.pairs {
+		touching[pair] = true
+		p.events = append(p.events, hits[j]...)
+	}
+	p.touching = touching
+}
+
+// detectBodyHit формирует событие начала контакта, если тела пары не касались на прошлом тике
+func (p *PhysicsEngine) detectBodyHit(pair BodyPair, events *eventBuffer) {
+	if p.touching[pair] {
+		return
+	}
+
+	a, b := pair.A, pair.B
+	axis, _, direction := penetrationAxis(*a.Collider, *b.Collider)
+	normal := mgl32.Vec3{}
+	normal[axis] = direction
+
+	events.emit(BodyHitEvent{
+		A:                a,
+		B:                b,
+		Normal:           normal,
+		RelativeVelocity: b.Velocity.Sub(a.Velocity),
+	})
+}
+
+// indexOf возвращает порядковые номера тел
+func indexOf(bodies []*RigidBody) map[*RigidBody]int {
+	index := make(map[*RigidBody]int, len(bodies))
+	for i, body := range bodies {
+		index[body] = i
+	}
+	return index
+}
+
+// pairIndices возвращает номера тел пар, пропуская пары с телами, отсутствующими в index
+func pairIndices(pairs []BodyPair, index map[*RigidBody]int) [][2]int {
+	result := make([][2]int, 0, len(pairs))
+	for _, pair := range pairs {
+		a, okA := index[pair.A]
+		b, okB := index[pair.B]
+		if okA && okB {
+			result = append(result, [2]int{a, b})
+		}
+	}
+	return result
+}
+
+// separate разводит два пересекающихся тела вдоль оси наименьшего проникновения.
+// Кинематические тела не смещаются, а двигают другое тело целиком.
+// Пары с вращающимися телами разводятся по повернутым боксам.
+func (p *PhysicsEngine) separate(a, b *RigidBody) {
+	if a.Kinematic && b.Kinematic {
+		return
+	}
+	if a.Kinematic {
+		p.pushByKinematic(a, b)
+		return
+	}
+	if b.Kinematic {
+		p.pushByKinematic(b, a)
+		return
+	}
+	if a.Rotates || b.Rotates {
+		p.separateOriented(a, b)
+		return
+	}
+
+	axis, depth, direction := penetrationAxis(*a.Collider, *b.Collider)
+	if depth <= CollisionEpsilon {
+		return
+	}
+
+	// direction указывает, куда нужно сдвинуть b относительно a
+	normal := mgl32.Vec3{}
+	normal[axis] = direction
+	p.pushApart(a, b, normal, depth)
+
+	// Гасим относительную скорость сближения (абсолютно неупругий удар)
+	va := a.Velocity[axis]
+	vb := b.Velocity[axis]
+	if (vb-va)*direction < 0 {
+		v := (a.Mass*va + b.Mass*vb) / (a.Mass + b.Mass)
+		a.Velocity[axis] = v
+		b.Velocity[axis] = v
+	}
+
+	// Тело, стоящее на другом теле, считается стоящим на земле
+	if axis == 1 {
+		if direction > 0 {
+			b.Grounded = true
+		} else {
+			a.Grounded = true
+		}
+	}
+}
+
+// pushApart раздвигает тела на глубину проникновения вдоль нормали, направленной от a к b.
+// Более легкое тело смещается сильнее; если одно тело уперлось в мир, остаток достается другому.
+func (p *PhysicsEngine) pushApart(a, b *RigidBody, normal mgl32.Vec3, depth float32) {
+	totalMass := a.Mass + b.Mass
+	resA := a.Move(normal.Mul(-depth*b.Mass/totalMass), p.world)
+	resB := b.Move(normal.Mul(depth*a.Mass/totalMass), p.world)
+
+	// Если какое-то тело не смогло сдвинуться (стена, пол), досдвигаем второе
+	achieved := resB.Actual.Sub(resA.Actual).Dot(normal)
+	if leftover := depth - achieved; leftover > CollisionEpsilon {
+		resA = a.Move(normal.Mul(-leftover), p.world)
+		leftover += resA.Actual.Dot(normal)
+		if leftover > CollisionEpsilon {
+			b.Move(normal.Mul(leftover), p.world)
+		}
+	}
+}
+
+// pushByKinematic выталкивает тело из кинематического тела.
+// Тело, оказавшееся сверху, стоит на платформе и переносится ею на следующем тике.
+func (p *PhysicsEngine) pushByKinematic(kinematic, body *RigidBody) {
+	axis, depth, direction := penetrationAxis(*kinematic.Collider, *body.Collider)
+	if depth <= CollisionEpsilon {
+		return
+	}
+
+	normal := mgl32.Vec3{}
+	normal[axis] = direction
+	body.Move(normal.Mul(depth), p.world)
+
+	// Тело не может двигаться навстречу платформе быстрее нее
+	if (body.Velocity[axis]-kinematic.Velocity[axis])*direction < 0 {
+		body.Velocity[axis] = kinematic.Velocity[axis]
+	}
+
+	if axis == 1 && direction > 0 {
+		body.Grounded = true
+		body.Platform = kinematic
+		// Стоящее на платформе тело движется вместе с ней по вертикали через перенос
+		body.Velocity[1] = 0
+	}
+}
+
+// penetrationAxis возвращает ось наименьшего проникновения двух боксов, его глубину
+// и направление (+1 или -1), в котором нужно сдвинуть второй бокс относительно первого.
+func penetrationAxis(a, b Box) (int, float32, float32) {
+	bestAxis := 0
+	bestDepth := float32(-1)
+	bestDirection := float32(1)
+
+	for axis := 0; axis < 3; axis++ {
+		overlap := minf(a.Max[axis], b.Max[axis]) - maxf(a.Min[axis], b.Min[axis])
+		if overlap <= 0 {
+			return 0, 0, 1
+		}
+		if bestDepth < 0 || overlap < bestDepth {
+			bestAxis = axis
+			bestDepth = overlap
+			if a.Min[axis]+a.Max[axis] <= b.Min[axis]+b.Max[axis] {
+				bestDirection = 1
+			} else {
+				bestDirection = -1
+			}
+		}
+	}
+
+	return bestAxis, bestDepth, bestDirection
+}
@@ -0,0 +1,92 @@
+package physics
+
+import (
+	"math"
+)
+
+// DefaultBroadphaseCellSize размер ячейки пространственного хеша по умолчанию
+const DefaultBroadphaseCellSize = 2.0
+
+// BodyPair представляет пару тел, коллайдеры которых пересекаются
+type BodyPair struct {
+	A, B *RigidBody
+}
+
+// cellKey идентифицирует ячейку пространственного хеша
+type cellKey struct {
+	x, y, z int32
+}
+
+// SpatialHash распределяет тела по ячейкам равномерной сетки для быстрого поиска соседей
+type SpatialHash struct {
+	cellSize float32
+	cells    map[cellKey][]*RigidBody
+}
+
+// NewSpatialHash создает пространственный хеш с заданным размером ячейки
+func NewSpatialHash(cellSize float32) *SpatialHash {
+	return &SpatialHash{
+		cellSize: cellSize,
+		cells:    make(map[cellKey][]*RigidBody),
+	}
+}
+
+// Clear удаляет все тела из хеша, сохраняя выделенную память
+func (h *SpatialHash) Clear() {
+	for key, bodies := range h.cells {
+		h.cells[key] = bodies[:0]
+	}
+}
+
+// Insert добавляет тело во все ячейки, которые пересекает его коллайдер
+func (h *SpatialHash) Insert(body *RigidBody) {
+	if body.Collider == nil {
+		body.UpdateCollider()
+	}
+	h.forEachCell(*body.Collider, func(key cellKey) {
+		h.cells[key] = append(h.cells[key], body)
+	})
+}
+
+// Query возвращает тела, коллайдеры которых пересекают область. Каждое тело возвращается один раз.
+func (h *SpatialHash) Query(region Box) []*RigidBody {
+	result := make([]*RigidBody, 0)
+	seen := make(map[*RigidBody]bool)
+
+	h.forEachCell(region, func(key cellKey) {
+		for _, body := range h.cells[key] {
+			if seen[body] {
+				continue
+			}
+			seen[body] = true
+			if body.Collider.Overlaps(region) {
+				result = append(result, body)
+			}
+		}
+	})
+
+	return result
+}
+
+// forEachCell вызывает функцию для каждой ячейки, которую пересекает бокс
+func (h *SpatialHash) forEachCell(box Box, fn func(cellKey)) {
+	min := h.cellOf(box.Min.X(), box.Min.Y(), box.Min.Z())
+	max := h.cellOf(box.Max.X(), box.Max.Y(), box.Max.Z())
+
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			for z := min.z; z <= max.z; z++ {
+				fn(cellKey{x, y, z})
+			}
+		}
+	}
+}
+
+// cellOf возвращает ячейку, содержащую точку
+func (h *SpatialHash) cellOf(x, y, z float32) cellKey {
+	return cellKey{
+		int32(math.Floor(float64(x / h.cellSize))),
+		int32(math.Floor(float64(y / h.cellSize))),
+		int32(math.Floor(float64(z / h.cellSize))),
+	}
+}
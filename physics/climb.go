@@ -0,0 +1,43 @@
+package physics
+
+const (
+	// Константы лазания
+	DefaultClimbSpeed   = 2.0
+	DefaultMaxClimbFall = 2.0
+)
+
+// ClimbableWorld сообщает, по каким блокам мира можно карабкаться.
+// Если мир движка реализует этот интерфейс, тела на лестницах падают не быстрее MaxClimbFall.
+type ClimbableWorld interface {
+	// Climbable проверяет, пересекает ли область блок, по которому можно карабкаться
+	Climbable(region Box) bool
+}
+
+// updateClimbing определяет, касается ли тело блока, по которому можно карабкаться
+func (p *PhysicsEngine) updateClimbing(body *RigidBody) {
+	body.Climbing = false
+
+	climbable, ok := p.world.(ClimbableWorld)
+	if !ok || body.Collider == nil || body.Flying {
+		return
+	}
+	body.Climbing = climbable.Climbable(*body.Collider)
+}
+
+// applyClimbing ограничивает скорость падения карабкающегося тела.
+// Падение по лестнице не накапливает высоту падения.
+func applyClimbing(body *RigidBody) {
+	if !body.Climbing {
+		return
+	}
+	if body.Velocity.Y() < -body.MaxClimbFall {
+		body.Velocity[1] = -body.MaxClimbFall
+	}
+	body.FallDistance = 0
+}
+
+// holdOnClimbable компенсирует поле тяжести прошлого тика, чтобы карабкающееся тело висело на месте
+func holdOnClimbable(body *RigidBody) {
+	body.Velocity[1] = 0
+	body.Force = body.Force.Sub(body.FieldAcceleration.Mul(body.Mass))
+}
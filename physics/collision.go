@@ -0,0 +1,170 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/geometry"
+)
+
+// CollisionEpsilon допуск при сравнении граней боксов, компенсирующий ошибки округления
+const CollisionEpsilon = geometry.Epsilon
+
+// edgeGuardStep шаг, с которым защита от края уменьшает горизонтальное смещение
+const edgeGuardStep = 0.05
+
+// CollisionWorld предоставляет формы коллизии статического мира (например, блоков)
+type CollisionWorld interface {
+	// CollisionBoxes возвращает формы коллизии в мировых координатах, пересекающие область
+	CollisionBoxes(region Box) []Box
+
+	// MaterialAt возвращает материал блока в ячейке, содержащей точку
+	MaterialAt(point mgl32.Vec3) Material
+}
+
+// MoveResult описывает результат перемещения тела с учетом коллизий
+type MoveResult struct {
+	// Запрошенное и фактическое смещение
+	Requested mgl32.Vec3
+	Actual    mgl32.Vec3
+
+	// Ground - тело уперлось в опору при движении вниз, Ceiling - в потолок при движении вверх
+	Ground  bool
+	Ceiling bool
+
+	// WallX и WallZ - движение по горизонтальной оси было остановлено стеной
+	WallX bool
+	WallZ bool
+
+	// Формы, остановившие движение
+	Contacts []Contact
+}
+
+// Contact описывает контакт тела с формой мира
+type Contact struct {
+	// Форма, с которой произошел контакт
+	Box Box
+
+	// Нормаль контакта, направленная от формы к телу
+	Normal mgl32.Vec3
+}
+
+// CollidedHorizontally проверяет, было ли горизонтальное движение остановлено стеной
+func (m MoveResult) CollidedHorizontally() bool {
+	return m.WallX || m.WallZ
+}
+
+// resolveMovement разрешает смещение бокса среди препятствий по осям: сначала Y, затем X и Z.
+// Вертикальное движение разрешается первым, чтобы корректно определять землю и потолок.
+func resolveMovement(obstacles []Box, box Box, movement mgl32.Vec3) MoveResult {
+	result := MoveResult{Requested: movement}
+
+	dy, hitY := clipMovement(obstacles, box, 1, movement.Y())
+	box = box.Translate(mgl32.Vec3{0, dy, 0})
+
+	dx, hitX := clipMovement(obstacles, box, 0, movement.X())
+	box = box.Translate(mgl32.Vec3{dx, 0, 0})
+
+	dz, hitZ := clipMovement(obstacles, box, 2, movement.Z())
+
+	result.Actual = mgl32.Vec3{dx, dy, dz}
+	result.Ground = movement.Y() < 0 && dy != movement.Y()
+	result.Ceiling = movement.Y() > 0 && dy != movement.Y()
+	result.WallX = dx != movement.X()
+	result.WallZ = dz != movement.Z()
+
+	// Запоминаем формы, остановившие движение, и нормали контакта
+	if result.Ground || result.Ceiling {
+		result.Contacts = append(result.Contacts, Contact{Box: hitY, Normal: mgl32.Vec3{0, -sign(movement.Y()), 0}})
+	}
+	if result.WallX {
+		result.Contacts = append(result.Contacts, Contact{Box: hitX, Normal: mgl32.Vec3{-sign(movement.X()), 0, 0}})
+	}
+	if result.WallZ {
+		result.Contacts = append(result.Contacts, Contact{Box: hitZ, Normal: mgl32.Vec3{0, 0, -sign(movement.Z())}})
+	}
+
+	return result
+}
+
+// stepUp пробует выполнить горизонтальное смещение, предварительно подняв бокс на высоту ступени,
+// и затем опускает его обратно на опору.
+func stepUp(obstacles []Box, box Box, movement mgl32.Vec3, stepHeight float32) MoveResult {
+	up := resolveMovement(obstacles, box, mgl32.Vec3{0, stepHeight, 0})
+	raised := box.Translate(up.Actual)
+
+	horizontal := resolveMovement(obstacles, raised, mgl32.Vec3{movement.X(), 0, movement.Z()})
+	moved := raised.Translate(horizontal.Actual)
+
+	down := resolveMovement(obstacles, moved, mgl32.Vec3{0, -up.Actual.Y() + minf(movement.Y(), 0), 0})
+
+	return MoveResult{
+		Requested: movement,
+		Actual:    up.Actual.Add(horizontal.Actual).Add(down.Actual),
+		Ground:    down.Ground,
+		WallX:     horizontal.WallX,
+		WallZ:     horizontal.WallZ,
+		Contacts:  append(horizontal.Contacts, down.Contacts...),
+	}
+}
+
+// clipMovement ограничивает смещение бокса вдоль оси всеми препятствиями.
+// Возвращает итоговое смещение и форму, которая ограничила его сильнее всего.
+func clipMovement(obstacles []Box, box Box, axis int, offset float32) (float32, Box) {
+	var hit Box
+	for _, obstacle := range obstacles {
+		if clipped := obstacle.ClipAxis(box, axis, offset); clipped != offset {
+			offset = clipped
+			hit = obstacle
+		}
+	}
+	return offset, hit
+}
+
+// guardEdges уменьшает горизонтальное смещение стоящего бокса так, чтобы под ним оставалась опора
+// не глубже depth. Если опоры нет уже в исходном положении, смещение не меняется.
+func guardEdges(obstacles []Box, box Box, movement mgl32.Vec3, depth float32) mgl32.Vec3 {
+	// Опора ищется только в тонком слое под подошвой: препятствия сбоку от тела опорой не считаются
+	sole := box
+	sole.Max[1] = box.Min.Y()
+	sole.Min[1] = box.Min.Y() - depth
+
+	supported := func(dx, dz float32) bool {
+		probe := sole.Translate(mgl32.Vec3{dx, 0, dz})
+		for _, obstacle := range obstacles {
+			if probe.Overlaps(obstacle) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !supported(0, 0) {
+		return movement
+	}
+
+	dx, dz := movement.X(), movement.Z()
+	for dx != 0 && !supported(dx, 0) {
+		dx = shrinkToward0(dx, edgeGuardStep)
+	}
+	for dz != 0 && !supported(0, dz) {
+		dz = shrinkToward0(dz, edgeGuardStep)
+	}
+	for dx != 0 && dz != 0 && !supported(dx, dz) {
+		dx = shrinkToward0(dx, edgeGuardStep)
+		dz = shrinkToward0(dz, edgeGuardStep)
+	}
+
+	return mgl32.Vec3{dx, movement.Y(), dz}
+}
+
+// shrinkToward0 приближает число к нулю на step, не переходя через ноль
+func shrinkToward0(x, step float32) float32 {
+	if x > 0 {
+		return maxf(x-step, 0)
+	}
+	return minf(x+step, 0)
+}
+
+// horizontalLenSqr возвращает квадрат длины горизонтальной составляющей вектора
+func horizontalLenSqr(v mgl32.Vec3) float32 {
+	return v.X()*v.X() + v.Z()*v.Z()
+}
@@ -1,91 +1,393 @@
 package physics
 
 import (
+	"math"
+
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 // PhysicsEngine применяет физические вычисления к зарегистрированным RigidBody.
-// Метод Tick продвигает симуляцию и вычисляет ускорение, скорость и позицию из приложенных сил.
+// Метод Tick продвигает симуляцию и вычисляет ускорение, скорость и позицию из приложенных сил,
+// разрешая коллизии тел с миром. Тела обрабатываются в порядке регистрации,
+// поэтому одинаковый ввод дает одинаковый результат.
 type PhysicsEngine struct {
+	bodies        []*RigidBody
 	registrations map[*RigidBody]bool
+	controllers   []*MovementController
+	world         CollisionWorld
+
+	// Количество выполненных тиков
+	tick uint64
+
+	// Количество горутин, между которыми распределяются независимые острова тел (1 - без параллелизма).
+	// При значении больше 1 мир должен допускать одновременное чтение из нескольких горутин.
+	Workers int
+
+	// Количество тиков покоя, после которого тело засыпает (0 - тела не засыпают),
+	// и скорость, ниже которой тело считается покоящимся
+	SleepTicks    int
+	SleepVelocity float32
+
+	// Широкая фаза столкновений тел друг с другом и пары, найденные на последнем тике
+	broadphase *SpatialHash
+	pairs      []BodyPair
+	// Пары, пересекавшиеся на прошлом тике (для событий начала контакта)
+	touching map[BodyPair]bool
+
+	// Снаряды и номер, присвоенный последнему зарегистрированному снаряду
+	projectiles      []*Projectile
+	projectileSerial uint64
+
+	// Объемы сил в порядке регистрации и в порядке применения на текущем тике
+	volumes       []*ForceVolume
+	activeVolumes []*ForceVolume
+
+	// Датчики и события, накопленные за текущий тик
+	sensors []*Sensor
+	events  eventBuffer
+
+	// Запись журнала симуляции (nil - журнал не ведется) и повтор журнала (nil - журнал не повторяется)
+	trace  *traceRecorder
+	replay *traceReplay
+
+	// Обработчики событий, вызываемые в конце тика
+	OnBlockHit    func(BlockHitEvent)
+	OnBodyHit     func(BodyHitEvent)
+	OnLanded      func(LandedEvent)
+	OnSensorEnter func(SensorEvent)
+	OnSensorExit  func(SensorEvent)
+
+	OnProjectileHit func(ProjectileHitEvent)
+	OnExplosion     func(ExplosionEvent)
 }
 
-// NewPhysicsEngine создает новый физический движок
-func NewPhysicsEngine() *PhysicsEngine {
+// NewPhysicsEngine создает новый физический движок, сталкивающий тела с заданным миром.
+// Если мир равен nil, тела движутся без коллизий.
+func NewPhysicsEngine(world CollisionWorld) *PhysicsEngine {
 	return &PhysicsEngine{
 		registrations: make(map[*RigidBody]bool),
+		world:         world,
+		Workers:       1,
+		SleepTicks:    DefaultSleepTicks,
+		SleepVelocity: DefaultSleepVelocity,
+		broadphase:    NewSpatialHash(DefaultBroadphaseCellSize),
+		touching:      make(map[BodyPair]bool),
 	}
 }
 
+// SetWorld заменяет мир, с которым сталкиваются тела, и пробуждает все тела
+func (p *PhysicsEngine) SetWorld(world CollisionWorld) {
+	p.world = world
+	for _, body := range p.bodies {
+		p.Wake(body)
+	}
+}
+
+// World возвращает мир, с которым сталкиваются тела
+func (p *PhysicsEngine) World() CollisionWorld {
+	return p.world
+}
+
 // Tick обновляет симуляцию.
-// Обновляет все зарегистрированные тела.
+// Применяет ввод контроллеров движения, обновляет все зарегистрированные тела, затем разрешает
+// столкновения тел друг с другом, обновляет датчики и передает накопленные события обработчикам.
+// Спящие тела пропускаются, пока их не потревожат.
 func (p *PhysicsEngine) Tick(delta float64) {
-	for rb := range p.registrations {
-		p.update(rb, delta)
-		if rb.OnPositionUpdated != nil {
+	p.tick++
+	if p.trace != nil {
+		p.trace.beginTick(p, delta)
+	}
+
+	// Контроллеры меняют только свои тела
+	controllers := p.controllers
+	p.parallel(len(controllers), func(i int) {
+		controllers[i].Update(delta, p.world)
+	})
+
+	// Обработчики событий могут менять регистрацию, поэтому работаем с копией
+	bodies := p.Bodies()
+
+	// Сначала двигаем кинематические тела вместе со стоящими на них телами
+	for _, rb := range bodies {
+		if rb.Kinematic {
+			p.moveKinematic(rb, delta)
+		}
+	}
+	// Опора на платформы определяется заново при разрешении столкновений
+	for _, rb := range bodies {
+		rb.Platform = nil
+	}
+	p.wakeDisturbed(bodies)
+	p.activeVolumes = p.sortedVolumes()
+
+	// Тела интегрируются независимо друг от друга, поэтому распределяются по горутинам поодиночке,
+	// а события собираются отдельно для каждого тела
+	events := make([]eventBuffer, len(bodies))
+	p.parallel(len(bodies), func(i int) {
+		if !bodies[i].Sleeping && !bodies[i].Kinematic {
+			p.update(bodies[i], delta, &events[i])
+		}
+	})
+	for _, buffer := range events {
+		p.events = append(p.events, buffer...)
+	}
+
+	p.resolveBodyCollisions(bodies)
+	p.updateProjectiles(delta)
+	p.updateSensors()
+
+	p.beginHandlers()
+	for _, rb := range bodies {
+		if rb.OnPositionUpdated != nil && !rb.Sleeping {
 			rb.OnPositionUpdated(rb)
 		}
 	}
+	p.endHandlers(tracePositionHandlers)
+
+	p.updateSleep(bodies, delta)
+	p.beginHandlers()
+	p.dispatchEvents()
+	p.endHandlers(traceEventHandlers)
+
+	if p.trace != nil {
+		p.trace.endTick(p)
+	}
+}
+
+// Ticks возвращает количество выполненных тиков
+func (p *PhysicsEngine) Ticks() uint64 {
+	return p.tick
+}
+
+// Bodies возвращает зарегистрированные тела в порядке регистрации
+func (p *PhysicsEngine) Bodies() []*RigidBody {
+	bodies := make([]*RigidBody, len(p.bodies))
+	copy(bodies, p.bodies)
+	return bodies
+}
+
+// Pairs возвращает пары пересекавшихся тел, найденные на последнем тике
+func (p *PhysicsEngine) Pairs() []BodyPair {
+	pairs := make([]BodyPair, len(p.pairs))
+	copy(pairs, p.pairs)
+	return pairs
+}
+
+// QueryRegion возвращает зарегистрированные тела, коллайдеры которых пересекают область.
+// Использует пространственный хеш, построенный на последнем тике.
+func (p *PhysicsEngine) QueryRegion(region Box) []*RigidBody {
+	candidates := p.broadphase.Query(region)
+	result := make([]*RigidBody, 0, len(candidates))
+	for _, body := range candidates {
+		if p.registrations[body] {
+			result = append(result, body)
+		}
+	}
+	return result
+}
+
+// Overlapping возвращает тела, пересекающиеся с заданным телом
+func (p *PhysicsEngine) Overlapping(body *RigidBody) []*RigidBody {
+	if body.Collider == nil {
+		return nil
+	}
+	result := make([]*RigidBody, 0)
+	for _, other := range p.QueryRegion(*body.Collider) {
+		if other != body {
+			result = append(result, other)
+		}
+	}
+	return result
 }
 
 // Register регистрирует RigidBody для обработки на каждом тике.
 func (p *PhysicsEngine) Register(body *RigidBody) {
+	if p.registrations[body] {
+		return
+	}
 	p.registrations[body] = true
+	p.bodies = append(p.bodies, body)
 }
 
 // Unregister отменяет регистрацию RigidBody.
 func (p *PhysicsEngine) Unregister(body *RigidBody) {
+	if !p.registrations[body] {
+		return
+	}
 	delete(p.registrations, body)
+	for i, b := range p.bodies {
+		if b == body {
+			p.bodies = append(p.bodies[:i], p.bodies[i+1:]...)
+			break
+		}
+	}
+
+	// Забываем контакты тела, чтобы снимки и журналы ссылались только на зарегистрированные тела
+	pairs := p.pairs[:0]
+	for _, pair := range p.pairs {
+		if pair.A != body && pair.B != body {
+			pairs = append(pairs, pair)
+		}
+	}
+	p.pairs = pairs
+	for pair := range p.touching {
+		if pair.A == body || pair.B == body {
+			delete(p.touching, pair)
+		}
+	}
+}
+
+// RegisterController регистрирует контроллер движения, обновляемый в начале каждого тика.
+// Тело контроллера регистрируется автоматически.
+func (p *PhysicsEngine) RegisterController(controller *MovementController) {
+	for _, c := range p.controllers {
+		if c == controller {
+			return
+		}
+	}
+	p.controllers = append(p.controllers, controller)
+	p.Register(controller.Body)
+}
+
+// UnregisterController отменяет регистрацию контроллера движения и его тела.
+func (p *PhysicsEngine) UnregisterController(controller *MovementController) {
+	for i, c := range p.controllers {
+		if c == controller {
+			p.controllers = append(p.controllers[:i], p.controllers[i+1:]...)
+			break
+		}
+	}
+	p.Unregister(controller.Body)
 }
 
 // update обновляет физическое тело с применением физических законов.
-func (p *PhysicsEngine) update(body *RigidBody, delta float64) {
-	// Обрабатываем гравитацию только если не на земле и не в режиме полета
-	if !body.Grounded && !body.Flying {
-		// Применяем гравитационную силу
-		gravityForce := mgl32.Vec3{0, body.Mass * -body.Gravity, 0}
-		body.Force = body.Force.Add(gravityForce)
+// Изменяет только само тело и может выполняться одновременно для разных тел.
+func (p *PhysicsEngine) update(body *RigidBody, delta float64, events *eventBuffer) {
+	// Конвейеры переносят стоящее тело, прыжковые платформы подбрасывают его
+	body.UpdateCollider()
+	effect := bodyEffect(body, p.activeVolumes)
+	body.FieldAcceleration = effect.acceleration
+	applyVolumeMotion(body, effect, delta)
 
-		// Выводим отладочную информацию
-		// fmt.Printf("Гравитация применена: %v, Скорость: %v\n", gravityForce, body.Velocity)
+	// Приложенная сила или смещение прерывают покой тела
+	if body.Force != (mgl32.Vec3{}) || body.Movement != (mgl32.Vec3{}) || body.Torque != (mgl32.Vec3{}) {
+		body.sleepTimer = 0
 	}
 
+	// Определяем погружение в жидкость: она выталкивает тело и гасит его скорость
+	fluid := p.updateFluid(body)
+	p.updateClimbing(body)
+
+	// Гравитация с учетом объемов сил действует всегда, кроме режима полета: стоящее тело
+	// прижимается к опоре, а сошедшее с края начинает падать
+	body.Force = body.Force.Add(body.FieldAcceleration.Mul(body.Mass))
+	applyFluid(body, fluid)
+
 	// Вычисляем ускорение из силы
 	acc := body.Force.Mul(1 / body.Mass)
 
 	// Обновляем скорость с учетом ускорения
 	body.Velocity = body.Velocity.Add(acc.Mul(float32(delta)))
 
-	// Ограничиваем максимальную скорость падения
-	if body.Velocity.Y() < DefaultTerminalVelocity {
-		body.Velocity = mgl32.Vec3{body.Velocity.X(), DefaultTerminalVelocity, body.Velocity.Z()}
+	// Ограничиваем максимальную скорость падения вдоль гравитации; ветер скорость не ограничивает
+	if gravity := verticalField(body.FieldAcceleration); gravity.Len() > 0 {
+		direction := gravity.Normalize()
+		if fall := body.Velocity.Dot(direction); fall > -DefaultTerminalVelocity {
+			body.Velocity = body.Velocity.Sub(direction.Mul(fall + DefaultTerminalVelocity))
+		}
 	}
 
-	// Если на земле, обнуляем вертикальную составляющую скорости
-	if body.Grounded && body.Velocity.Y() < 0 {
-		body.Velocity = mgl32.Vec3{body.Velocity.X(), 0, body.Velocity.Z()}
+	// Трение опоры на земле и сопротивление воздуха в полете гасят горизонтальную скорость.
+	// Скорость тел с контроллером движения гасит сам контроллер,
+	// вращающиеся тела тормозятся трением в точках контакта
+	material := body.GroundMaterial
+	if !body.Grounded {
+		material = DefaultMaterial
+	}
+	if !body.SelfPropelled {
+		switch {
+		case !body.Grounded:
+			dampHorizontal(body, body.AirDrag, delta)
+		case !body.Rotates:
+			dampHorizontal(body, material.Friction, delta)
+		}
+	}
+	dampFluid(body, fluid, delta)
+	applyClimbing(body)
+	if body.Rotates {
+		p.rotate(body, delta)
 	}
 
-	// Вычисляем изменение позиции
-	dpos := body.Velocity.Mul(float32(delta))
+	// Вычисляем изменение позиции, включая дополнительное смещение.
+	// Вязкие поверхности замедляют горизонтальное перемещение
+	dpos := body.Velocity.Mul(float32(delta)).Add(body.Movement)
+	dpos[0] *= material.SpeedFactor
+	dpos[2] *= material.SpeedFactor
+	body.Movement = mgl32.Vec3{}
 
 	// Сохраняем предыдущую позицию в историю
 	body.AppendHistory()
 
-	// Обновляем позицию
-	body.Position = body.Position.Add(dpos)
+	// Перемещаем тело с разрешением коллизий, это же обновляет Grounded
+	velocity := body.Velocity
+	wasGrounded := body.Grounded
+	result := body.Move(dpos, p.world)
+	collectContactEvents(body, result, velocity, wasGrounded, events)
 
-	// Обновляем коллайдер
-	body.UpdateCollider()
+	// Определяем материал опоры и отскакиваем от упругих поверхностей
+	p.updateGroundMaterial(body)
+	if body.Rotates {
+		if p.resolveRotatingContacts(body, result, velocity) {
+			body.Grounded = false
+		}
+	} else if result.Ground && -velocity.Y() > MinBounceSpeed && body.GroundMaterial.Bounciness > 0 {
+		body.Velocity[1] = -velocity.Y() * body.GroundMaterial.Bounciness
+		body.Grounded = false
+	}
 
 	// Обновляем пройденное расстояние
-	body.TripDistance += dpos.Len()
+	moved := result.Actual.Len()
+	body.TripDistance += moved
 
 	// Сбрасываем пройденное расстояние, если тело не движется
-	if dpos.Len() == 0 && body.TripDistance > 0 {
+	if moved == 0 && body.TripDistance > 0 {
 		body.TripDistance = 0
 	}
 
-	// Сбрасываем силу
+	// Сбрасываем силу, запоминая ее для журнала
+	body.appliedForce = body.Force
 	body.Force = mgl32.Vec3{}
+	body.Torque = mgl32.Vec3{}
+}
+
+// updateGroundMaterial определяет материал опоры стоящего тела: из форм мира под подошвой
+// выбирается та, что перекрывает наибольшую площадь подошвы (тело, стоящее на краю льда или плиты,
+// получает материал блока, на который опирается большей частью)
+func (p *PhysicsEngine) updateGroundMaterial(body *RigidBody) {
+	body.GroundMaterial = DefaultMaterial
+	if !body.Grounded || p.world == nil || body.Collider == nil {
+		return
+	}
+
+	// Нижняя грань коллайдера совпадает с ногами, у вращающегося тела - с нижней вершиной
+	sole := *body.Collider
+	sole.Max[1] = sole.Min.Y()
+	sole.Min[1] -= groundProbeDepth
+
+	best := float32(0)
+	for _, box := range p.world.CollisionBoxes(sole) {
+		overlap := sole.Intersect(box)
+		size := overlap.Size()
+		if area := maxf(size.X(), 0) * maxf(size.Z(), 0); area > best && size.Y() > 0 {
+			best = area
+			body.GroundMaterial = p.world.MaterialAt(overlap.Center())
+		}
+	}
+}
+
+// dampHorizontal экспоненциально гасит горизонтальную скорость тела с заданной скоростью затухания
+func dampHorizontal(body *RigidBody, rate float32, delta float64) {
+	factor := float32(math.Exp(-float64(rate) * delta))
+	body.Velocity[0] *= factor
+	body.Velocity[2] *= factor
 }
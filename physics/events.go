@@ -0,0 +1,299 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Face обозначает грань блока
+type Face uint8
+
+const (
+	FaceNone Face = iota
+	FaceDown
+	FaceUp
+	FaceNorth
+	FaceSouth
+	FaceWest
+	FaceEast
+)
+
+// FaceFromNormal возвращает грань, внешняя нормаль которой совпадает с вектором.
+// Север соответствует -Z, запад - -X.
+func FaceFromNormal(normal mgl32.Vec3) Face {
+	switch {
+	case normal.Y() > 0:
+		return FaceUp
+	case normal.Y() < 0:
+		return FaceDown
+	case normal.Z() < 0:
+		return FaceNorth
+	case normal.Z() > 0:
+		return FaceSouth
+	case normal.X() < 0:
+		return FaceWest
+	case normal.X() > 0:
+		return FaceEast
+	}
+	return FaceNone
+}
+
+// BlockHitEvent возникает, когда тело начинает касаться блока гранью
+type BlockHitEvent struct {
+	Body *RigidBody
+
+	// Координаты блока (ячейка, содержащая центр формы) и сама форма
+	Block [3]int
+	Box   Box
+
+	// Грань блока, в которую ударилось тело
+	Face Face
+
+	// Скорость тела в момент удара
+	Velocity mgl32.Vec3
+}
+
+// ImpactSpeed возвращает скорость удара вдоль нормали грани
+func (e BlockHitEvent) ImpactSpeed() float32 {
+	return float32(math.Abs(float64(e.Velocity.Dot(faceNormal(e.Face)))))
+}
+
+// BodyHitEvent возникает, когда два тела начинают пересекаться
+type BodyHitEvent struct {
+	A, B *RigidBody
+
+	// Нормаль от A к B
+	Normal mgl32.Vec3
+
+	// Относительная скорость B относительно A в момент удара
+	RelativeVelocity mgl32.Vec3
+}
+
+// LandedEvent возникает, когда падающее тело встает на опору
+type LandedEvent struct {
+	Body *RigidBody
+
+	// Высота падения
+	FallDistance float32
+
+	// Скорость в момент приземления
+	Velocity mgl32.Vec3
+}
+
+// SensorEvent возникает при входе тела в объем датчика или выходе из него
+type SensorEvent struct {
+	Sensor *Sensor
+	Body   *RigidBody
+}
+
+// Sensor - объем, отслеживающий вход и выход тел (нажимные плиты, триггеры)
+type Sensor struct {
+	Name string
+	Box  Box
+
+	// Тела, находящиеся внутри датчика, в порядке входа
+	occupants []*RigidBody
+}
+
+// NewSensor создает датчик с заданным объемом
+func NewSensor(name string, box Box) *Sensor {
+	return &Sensor{
+		Name: name,
+		Box:  box,
+	}
+}
+
+// Contains проверяет, находится ли тело внутри датчика
+func (s *Sensor) Contains(body *RigidBody) bool {
+	return containsBody(s.occupants, body)
+}
+
+// Occupants возвращает количество тел внутри датчика
+func (s *Sensor) Occupants() int {
+	return len(s.occupants)
+}
+
+// faceNormal возвращает внешнюю нормаль грани
+func faceNormal(face Face) mgl32.Vec3 {
+	switch face {
+	case FaceUp:
+		return mgl32.Vec3{0, 1, 0}
+	case FaceDown:
+		return mgl32.Vec3{0, -1, 0}
+	case FaceNorth:
+		return mgl32.Vec3{0, 0, -1}
+	case FaceSouth:
+		return mgl32.Vec3{0, 0, 1}
+	case FaceWest:
+		return mgl32.Vec3{-1, 0, 0}
+	case FaceEast:
+		return mgl32.Vec3{1, 0, 0}
+	}
+	return mgl32.Vec3{}
+}
+
+// faceBit возвращает бит грани в маске контактов
+func faceBit(face Face) uint8 {
+	return 1 << face
+}
+
+// blockOf возвращает ячейку блока, содержащую центр формы
+func blockOf(box Box) [3]int {
+	return cellOf(box.Min.Add(box.Max).Mul(0.5))
+}
+
+// cellOf возвращает ячейку блока, содержащую точку
+func cellOf(point mgl32.Vec3) [3]int {
+	return [3]int{
+		int(math.Floor(float64(point.X()))),
+		int(math.Floor(float64(point.Y()))),
+		int(math.Floor(float64(point.Z()))),
+	}
+}
+
+// emit откладывает событие до конца тика
+func (p *PhysicsEngine) emit(event interface{}) {
+	p.events.emit(event)
+}
+
+// dispatchEvents передает накопленные события обработчикам в порядке возникновения
+func (p *PhysicsEngine) dispatchEvents() {
+	events := p.events
+	p.events = nil
+
+	for _, event := range events {
+		switch e := event.(type) {
+		case BlockHitEvent:
+			if p.OnBlockHit != nil {
+				p.OnBlockHit(e)
+			}
+		case BodyHitEvent:
+			if p.OnBodyHit != nil {
+				p.OnBodyHit(e)
+			}
+		case LandedEvent:
+			if p.OnLanded != nil {
+				p.OnLanded(e)
+			}
+		case sensorEnterEvent:
+			if p.OnSensorEnter != nil {
+				p.OnSensorEnter(SensorEvent(e))
+			}
+		case sensorExitEvent:
+			if p.OnSensorExit != nil {
+				p.OnSensorExit(SensorEvent(e))
+			}
+		case ProjectileHitEvent:
+			if e.Projectile.OnHit != nil {
+				e.Projectile.OnHit(e)
+			}
+			if p.OnProjectileHit != nil {
+				p.OnProjectileHit(e)
+			}
+		case ExplosionEvent:
+			if p.OnExplosion != nil {
+				p.OnExplosion(e)
+			}
+		}
+	}
+}
+
+// Внутренние типы, различающие вход и выход из датчика в очереди событий
+type sensorEnterEvent SensorEvent
+type sensorExitEvent SensorEvent
+
+// RegisterSensor добавляет датчик в движок
+func (p *PhysicsEngine) RegisterSensor(sensor *Sensor) {
+	for _, s := range p.sensors {
+		if s == sensor {
+			return
+		}
+	}
+	p.sensors = append(p.sensors, sensor)
+}
+
+// UnregisterSensor удаляет датчик из движка
+func (p *PhysicsEngine) UnregisterSensor(sensor *Sensor) {
+	for i, s := range p.sensors {
+		if s == sensor {
+			p.sensors = append(p.sensors[:i], p.sensors[i+1:]...)
+			return
+		}
+	}
+}
+
+// collectContactEvents формирует события удара о блоки и приземления по результату перемещения
+func collectContactEvents(body *RigidBody, result MoveResult, velocity mgl32.Vec3, wasGrounded bool, events *eventBuffer) {
+	faces := uint8(0)
+	for _, contact := range result.Contacts {
+		// Тело ударяется о грань блока, обращенную к нему
+		face := FaceFromNormal(contact.Normal)
+		faces |= faceBit(face)
+		if body.contactFaces&faceBit(face) != 0 {
+			continue
+		}
+		events.emit(BlockHitEvent{
+			Body:     body,
+			Block:    blockOf(contact.Box),
+			Box:      contact.Box,
+			Face:     face,
+			Velocity: velocity,
+		})
+	}
+	body.contactFaces = faces
+
+	// Высота падения накапливается только при движении вниз
+	if !body.Grounded {
+		if result.Actual.Y() < 0 {
+			body.FallDistance -= result.Actual.Y()
+		} else if result.Actual.Y() > 0 {
+			body.FallDistance = 0
+		}
+		return
+	}
+
+	if !wasGrounded {
+		events.emit(LandedEvent{
+			Body:         body,
+			FallDistance: body.FallDistance - result.Actual.Y(),
+			Velocity:     velocity,
+		})
+	}
+	body.FallDistance = 0
+}
+
+// updateSensors определяет, какие тела вошли в датчики и вышли из них
+func (p *PhysicsEngine) updateSensors() {
+	for _, sensor := range p.sensorList() {
+		inside := p.QueryRegion(sensor.Box)
+		for _, body := range inside {
+			if !containsBody(sensor.occupants, body) {
+				p.emit(sensorEnterEvent{Sensor: sensor, Body: body})
+			}
+		}
+		for _, body := range sensor.occupants {
+			if !containsBody(inside, body) {
+				p.emit(sensorExitEvent{Sensor: sensor, Body: body})
+			}
+		}
+		sensor.occupants = inside
+	}
+}
+
+// sensorList возвращает копию списка зарегистрированных датчиков
+func (p *PhysicsEngine) sensorList() []*Sensor {
+	sensors := make([]*Sensor, len(p.sensors))
+	copy(sensors, p.sensors)
+	return sensors
+}
+
+// containsBody проверяет, есть ли тело в срезе
+func containsBody(bodies []*RigidBody, body *RigidBody) bool {
+	for _, b := range bodies {
+		if b == body {
+			return true
+		}
+	}
+	return false
+}
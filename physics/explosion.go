@@ -0,0 +1,183 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/geometry"
+)
+
+const (
+	// Количество лучей вдоль ребра куба направлений, по которым распространяется взрыв
+	ExplosionRayGrid = 16
+
+	// Шаг луча взрыва и ослабление луча на каждом шаге
+	ExplosionRayStep        = 0.3
+	ExplosionRayAttenuation = 0.225
+
+	// Радиус воздействия на тела в единицах мощности взрыва
+	ExplosionRadiusFactor = 2.0
+
+	// Импульс (Н·с), получаемый полностью открытым телом в центре взрыва единичной мощности
+	DefaultExplosionImpulse = 100.0
+
+	// Количество точек вдоль каждой оси коллайдера, проверяемых на видимость из центра взрыва
+	explosionExposureSamples = 3
+)
+
+// ExplosionWorld позволяет взрывам разрушать блоки.
+// Если мир движка реализует этот интерфейс, Explode разрушает блоки в зависимости от их стойкости.
+type ExplosionWorld interface {
+	// BlastResistance возвращает стойкость блока к взрыву. ok равен false для пустой ячейки.
+	// Отрицательная стойкость делает блок неразрушимым.
+	BlastResistance(block [3]int) (resistance float32, ok bool)
+
+	// DestroyBlock удаляет блок, разрушенный взрывом
+	DestroyBlock(block [3]int)
+}
+
+// ExplosionEvent описывает последствия взрыва
+type ExplosionEvent struct {
+	Center mgl32.Vec3
+	Power  float32
+
+	// Разрушенные блоки в порядке обнаружения (для выпадения предметов и эффектов)
+	Blocks [][3]int
+
+	// Тела, получившие импульс
+	Bodies []*RigidBody
+}
+
+// Explode производит взрыв заданной мощности.
+// Лучи, расходящиеся из центра, теряют силу в каждом блоке пропорционально его стойкости
+// и разрушают блоки, до которых дошли. Затем тела в радиусе получают импульс от центра,
+// ослабленный расстоянием и долей коллайдера, не закрытой уцелевшими блоками.
+// Событие передается обработчику OnExplosion в конце тика.
+func (p *PhysicsEngine) Explode(center mgl32.Vec3, power float32) ExplosionEvent {
+	event := ExplosionEvent{Center: center, Power: power}
+	if power <= 0 {
+		return event
+	}
+
+	if world, ok := p.world.(ExplosionWorld); ok {
+		event.Blocks = explosionBlocks(world, center, power)
+		for _, block := range event.Blocks {
+			world.DestroyBlock(block)
+		}
+	}
+
+	radius := power * ExplosionRadiusFactor
+	area := geometry.BoxAround(center, mgl32.Vec3{radius, radius, radius})
+	for _, body := range p.bodies {
+		if body.Kinematic || body.Mass <= 0 || body.Collider == nil || !body.Collider.Overlaps(area) {
+			continue
+		}
+
+		offset := body.Collider.Center().Sub(center)
+		distance := offset.Len()
+		if distance >= radius {
+			continue
+		}
+		exposure := p.exposure(center, *body.Collider)
+		if exposure == 0 {
+			continue
+		}
+
+		// Тело в самом центре отбрасывается вверх
+		direction := mgl32.Vec3{0, 1, 0}
+		if distance > 0 {
+			direction = offset.Mul(1 / distance)
+		}
+		impulse := DefaultExplosionImpulse * power * (1 - distance/radius) * exposure
+		body.Velocity = body.Velocity.Add(direction.Mul(impulse / body.Mass))
+		if body.Velocity.Y() > 0 {
+			body.Grounded = false
+		}
+		event.Bodies = append(event.Bodies, body)
+	}
+
+	p.emit(event)
+	return event
+}
+
+// explosionBlocks трассирует лучи взрыва и возвращает блоки, которые нужно разрушить
+func explosionBlocks(world ExplosionWorld, center mgl32.Vec3, power float32) [][3]int {
+	blocks := make([][3]int, 0)
+	found := make(map[[3]int]bool)
+
+	const last = ExplosionRayGrid - 1
+	for i := 0; i <= last; i++ {
+		for j := 0; j <= last; j++ {
+			for k := 0; k <= last; k++ {
+				// Лучи проходят через точки на поверхности куба направлений
+				if i != 0 && i != last && j != 0 && j != last && k != 0 && k != last {
+					continue
+				}
+				direction := mgl32.Vec3{
+					float32(i)/last*2 - 1,
+					float32(j)/last*2 - 1,
+					float32(k)/last*2 - 1,
+				}.Normalize().Mul(ExplosionRayStep)
+
+				position := center
+				intensity := power
+				for intensity > 0 {
+					block := cellOf(position)
+					if resistance, ok := world.BlastResistance(block); ok {
+						if resistance < 0 {
+							break
+						}
+						intensity -= (resistance + ExplosionRayStep) * ExplosionRayStep
+						if intensity > 0 && !found[block] {
+							found[block] = true
+							blocks = append(blocks, block)
+						}
+					}
+					position = position.Add(direction)
+					intensity -= ExplosionRayAttenuation
+				}
+			}
+		}
+	}
+
+	return blocks
+}
+
+// exposure возвращает долю точек коллайдера, видимых из центра взрыва
+func (p *PhysicsEngine) exposure(center mgl32.Vec3, collider Box) float32 {
+	if p.world == nil {
+		return 1
+	}
+
+	// Точки на границе коллайдера не должны касаться опоры, на которой стоит тело
+	collider = collider.Grow(-CollisionEpsilon)
+	size := collider.Size()
+
+	visible, total := 0, 0
+	const last = explosionExposureSamples - 1
+	for i := 0; i <= last; i++ {
+		for j := 0; j <= last; j++ {
+			for k := 0; k <= last; k++ {
+				point := collider.Min.Add(mgl32.Vec3{
+					size.X() * float32(i) / last,
+					size.Y() * float32(j) / last,
+					size.Z() * float32(k) / last,
+				})
+				total++
+				if !p.occluded(geometry.Segment{Start: center, End: point}) {
+					visible++
+				}
+			}
+		}
+	}
+
+	return float32(visible) / float32(total)
+}
+
+// occluded проверяет, пересекает ли отрезок формы блоков мира
+func (p *PhysicsEngine) occluded(segment geometry.Segment) bool {
+	for _, box := range p.world.CollisionBoxes(segment.Bounds().Grow(CollisionEpsilon)) {
+		if _, _, ok := segment.IntersectBox(box); ok {
+			return true
+		}
+	}
+	return false
+}
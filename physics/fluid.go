@@ -0,0 +1,98 @@
+package physics
+
+import (
+	"math"
+)
+
+const (
+	// Константы движения в жидкости
+	DefaultBuoyancy  = 0.9
+	DefaultSwimSpeed = 2.0
+
+	// Множитель целевой скорости контроллера движения в жидкости
+	DefaultSwimSpeedMultiplier = 0.5
+
+	// Доля погружения, начиная с которой тело считается плывущим
+	SwimSubmersion = 0.4
+)
+
+// Fluid описывает физические свойства жидкости
+type Fluid struct {
+	// Density - плотность жидкости относительно воды; множитель выталкивающей силы
+	Density float32
+
+	// Drag - скорость затухания скорости полностью погруженного тела (1/с)
+	Drag float32
+}
+
+// FluidVolume описывает область, заполненную жидкостью
+type FluidVolume struct {
+	Box   Box
+	Fluid Fluid
+}
+
+// FluidWorld предоставляет объемы жидкостей мира.
+// Если мир движка реализует этот интерфейс, тела в жидкости всплывают и испытывают сопротивление.
+type FluidWorld interface {
+	// FluidVolumes возвращает объемы жидкостей в мировых координатах, пересекающие область
+	FluidVolumes(region Box) []FluidVolume
+}
+
+// updateFluid определяет погружение тела в жидкости мира.
+// Возвращает усредненные по объему погружения свойства жидкости.
+func (p *PhysicsEngine) updateFluid(body *RigidBody) Fluid {
+	body.Submersion = 0
+	body.InFluid = false
+
+	fluids, ok := p.world.(FluidWorld)
+	if !ok || body.Collider == nil {
+		return Fluid{}
+	}
+
+	collider := *body.Collider
+	volume := collider.Volume()
+	if volume == 0 {
+		return Fluid{}
+	}
+
+	var submerged float32
+	var fluid Fluid
+	for _, v := range fluids.FluidVolumes(collider) {
+		overlap := collider.Intersect(v.Box).Volume()
+		submerged += overlap
+		fluid.Density += v.Fluid.Density * overlap
+		fluid.Drag += v.Fluid.Drag * overlap
+	}
+	if submerged == 0 {
+		return Fluid{}
+	}
+
+	fluid.Density /= submerged
+	fluid.Drag /= submerged
+	body.Submersion = minf(submerged/volume, 1)
+	body.InFluid = true
+	return fluid
+}
+
+// applyFluid добавляет выталкивающую силу, направленную против гравитации и пропорциональную погруженному объему
+func applyFluid(body *RigidBody, fluid Fluid) {
+	if !body.InFluid || body.Flying {
+		return
+	}
+	buoyancy := -body.Mass * body.Buoyancy * fluid.Density * body.Submersion
+	body.Force = body.Force.Add(verticalField(body.FieldAcceleration).Mul(buoyancy))
+}
+
+// dampFluid гасит скорость тела сопротивлением жидкости.
+// Горизонтальную скорость тел с контроллером движения гасит сам контроллер.
+func dampFluid(body *RigidBody, fluid Fluid, delta float64) {
+	if !body.InFluid {
+		return
+	}
+	factor := float32(math.Exp(-float64(fluid.Drag*body.Submersion) * delta))
+	body.Velocity[1] *= factor
+	if !body.SelfPropelled {
+		body.Velocity[0] *= factor
+		body.Velocity[2] *= factor
+	}
+}
@@ -0,0 +1,116 @@
+package physics
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Минимальное количество единиц работы, при котором тик распределяется по горутинам
+const minParallelWork = 32
+
+// eventBuffer накапливает события, возникшие при обработке одного тела или пары тел.
+// Буферы объединяются в порядке тел и пар, поэтому порядок событий не зависит от числа горутин.
+type eventBuffer []interface{}
+
+// emit добавляет событие в буфер
+func (b *eventBuffer) emit(event interface{}) {
+	*b = append(*b, event)
+}
+
+// unionFind - система непересекающихся множеств для разбиения тел на острова
+type unionFind []int
+
+// newUnionFind создает n одиночных множеств
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+// find возвращает корень множества элемента
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+// union объединяет множества двух элементов.
+// Корнем становится меньший номер, поэтому результат не зависит от порядка объединения.
+func (u unionFind) union(a, b int) {
+	ra, rb := u.find(a), u.find(b)
+	switch {
+	case ra < rb:
+		u[rb] = ra
+	case rb < ra:
+		u[ra] = rb
+	}
+}
+
+// islands разбивает тела на острова - группы тел, связанных парами контактов.
+// Кинематические тела не объединяют острова и в них не входят: столкновения их не изменяют.
+// Возвращает номера тел каждого острова в порядке регистрации и номер острова каждого тела (-1 - вне островов).
+// Острова упорядочены по первому телу.
+func islands(bodies []*RigidBody, pairs [][2]int) ([][]int, []int) {
+	u := newUnionFind(len(bodies))
+	for _, pair := range pairs {
+		if !bodies[pair[0]].Kinematic && !bodies[pair[1]].Kinematic {
+			u.union(pair[0], pair[1])
+		}
+	}
+
+	result := make([][]int, 0)
+	island := make([]int, len(bodies))
+	roots := make(map[int]int)
+	for i, body := range bodies {
+		island[i] = -1
+		if body.Kinematic {
+			continue
+		}
+		root := u.find(i)
+		k, ok := roots[root]
+		if !ok {
+			k = len(result)
+			roots[root] = k
+			result = append(result, nil)
+		}
+		result[k] = append(result[k], i)
+		island[i] = k
+	}
+	return result, island
+}
+
+// parallel выполняет work для каждого номера от 0 до n-1 на пуле из Workers горутин.
+// Каждая единица работы должна изменять только свои данные.
+func (p *PhysicsEngine) parallel(n int, work func(i int)) {
+	workers := p.Workers
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 || n < minParallelWork {
+		for i := 0; i < n; i++ {
+			work(i)
+		}
+		return
+	}
+
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				work(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
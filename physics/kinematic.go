@@ -0,0 +1,162 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Path задает траекторию кинематического тела
+type Path interface {
+	// PositionAt возвращает положение тела через t секунд после начала движения
+	PositionAt(t float64) mgl32.Vec3
+}
+
+// WaypointPath - движение по точкам с постоянной скоростью.
+// Без Loop тело доходит до последней точки и возвращается обратно (лифт),
+// с Loop после последней точки направляется к первой (замкнутый маршрут).
+// Точки маршрута, созданного NewWaypointPath, после создания не меняются.
+type WaypointPath struct {
+	Points []mgl32.Vec3
+	Speed  float32
+	Loop   bool
+
+	// Заранее вычисленные отрезки маршрута и его длина
+	route *waypointRoute
+}
+
+// waypointRoute - отрезки маршрута с их длинами
+type waypointRoute struct {
+	points  []mgl32.Vec3
+	lengths []float32
+	total   float32
+}
+
+// NewWaypointPath создает маршрут по точкам с заданной скоростью (м/с)
+func NewWaypointPath(speed float32, loop bool, points ...mgl32.Vec3) *WaypointPath {
+	w := &WaypointPath{
+		Points: points,
+		Speed:  speed,
+		Loop:   loop,
+	}
+	w.route = w.buildRoute()
+	return w
+}
+
+// buildRoute строит отрезки маршрута: вперед и назад для челночного движения, с замыканием для кольцевого
+func (w *WaypointPath) buildRoute() *waypointRoute {
+	points := w.Points
+	if len(points) == 0 {
+		return &waypointRoute{}
+	}
+	if w.Loop {
+		points = append(append([]mgl32.Vec3{}, points...), points[0])
+	} else {
+		for i := len(w.Points) - 2; i >= 0; i-- {
+			points = append(points[:len(points):len(points)], w.Points[i])
+		}
+	}
+
+	route := &waypointRoute{points: points, lengths: make([]float32, len(points))}
+	for i := 1; i < len(points); i++ {
+		route.lengths[i] = points[i].Sub(points[i-1]).Len()
+		route.total += route.lengths[i]
+	}
+	return route
+}
+
+// PositionAt возвращает положение на маршруте в момент t
+func (w *WaypointPath) PositionAt(t float64) mgl32.Vec3 {
+	if len(w.Points) == 0 {
+		return mgl32.Vec3{}
+	}
+
+	// Маршрут, заданный литералом, строится при каждом вызове
+	route := w.route
+	if route == nil {
+		route = w.buildRoute()
+	}
+	points := route.points
+	if route.total == 0 || w.Speed <= 0 {
+		return points[0]
+	}
+
+	distance := float32(math.Mod(t*float64(w.Speed), float64(route.total)))
+	for i := 1; i < len(points); i++ {
+		length := route.lengths[i]
+		if distance <= length && length > 0 {
+			return points[i-1].Add(points[i].Sub(points[i-1]).Mul(distance / length))
+		}
+		distance -= length
+	}
+	return points[len(points)-1]
+}
+
+// OscillationPath - гармоническое колебание вокруг центра (качающиеся мосты, поршни)
+type OscillationPath struct {
+	Center    mgl32.Vec3
+	Amplitude mgl32.Vec3
+	Period    float64
+}
+
+// PositionAt возвращает положение колеблющегося тела в момент t
+func (o *OscillationPath) PositionAt(t float64) mgl32.Vec3 {
+	if o.Period <= 0 {
+		return o.Center
+	}
+	phase := float32(math.Sin(2 * math.Pi * t / o.Period))
+	return o.Center.Add(o.Amplitude.Mul(phase))
+}
+
+// NewKinematicBody создает кинематическое тело, движущееся по траектории.
+// Кинематическое тело не подвержено гравитации и коллизиям с миром, расталкивает обычные тела
+// и переносит тела, стоящие на нем. Если path равен nil, тело движется со своей скоростью Velocity.
+func NewKinematicBody(path Path, width, height float32) *RigidBody {
+	position := mgl32.Vec3{}
+	if path != nil {
+		position = path.PositionAt(0)
+	}
+
+	body := NewRigidBody(position, 0, width, height)
+	body.Kinematic = true
+	body.Path = path
+	body.UpdateCollider()
+	return body
+}
+
+// moveKinematic перемещает кинематическое тело по траектории и переносит стоящие на нем тела
+func (p *PhysicsEngine) moveKinematic(body *RigidBody, delta float64) {
+	// На паузе (нулевой шаг) тело стоит на месте и сохраняет скорость
+	if delta <= 0 {
+		return
+	}
+	body.AppendHistory()
+
+	previous := body.Position
+	if body.Path != nil {
+		body.pathTime += delta
+		body.Position = body.Path.PositionAt(body.pathTime)
+		body.Velocity = body.Position.Sub(previous).Mul(float32(1 / delta))
+	} else {
+		body.Position = body.Position.Add(body.Velocity.Mul(float32(delta)))
+	}
+	body.UpdateCollider()
+
+	moved := body.Position.Sub(previous)
+	body.TripDistance += moved.Len()
+	if moved.Len() == 0 {
+		return
+	}
+
+	// Переносим тела, стоявшие на платформе на прошлом тике. Стены мира при этом останавливают их
+	for _, rider := range p.bodies {
+		if rider.Platform != body {
+			continue
+		}
+		grounded := rider.Grounded
+		velocity := rider.Velocity
+		rider.Move(moved, p.world)
+		rider.Grounded = grounded
+		rider.Velocity = velocity
+	}
+}
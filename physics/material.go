@@ -0,0 +1,31 @@
+package physics
+
+const (
+	// Константы материалов поверхностей
+	DefaultFriction    = 10.0
+	DefaultBounciness  = 0.0
+	DefaultSpeedFactor = 1.0
+	DefaultAirDrag     = 0.5
+
+	// Минимальная скорость удара, при которой упругая поверхность отбрасывает тело
+	MinBounceSpeed = 1.0
+)
+
+// Material описывает физические свойства поверхности блока
+type Material struct {
+	// Friction - скорость затухания горизонтальной скорости тела, стоящего на поверхности (1/с)
+	Friction float32
+
+	// Bounciness - доля вертикальной скорости, сохраняемая при отскоке от поверхности (0..1)
+	Bounciness float32
+
+	// SpeedFactor - множитель горизонтального перемещения тела, стоящего на поверхности
+	SpeedFactor float32
+}
+
+// DefaultMaterial материал обычных блоков
+var DefaultMaterial = Material{
+	Friction:    DefaultFriction,
+	Bounciness:  DefaultBounciness,
+	SpeedFactor: DefaultSpeedFactor,
+}
@@ -4,25 +4,104 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-// MovementController управляет движением персонажа в мире
+const (
+	// Константы контроллера движения
+	DefaultSprintMultiplier   = 1.3
+	DefaultCrouchMultiplier   = 0.3
+	DefaultCrouchHeightRatio  = 0.83
+	DefaultGroundAcceleration = 40.0
+	DefaultGroundDeceleration = 30.0
+	DefaultAirAcceleration    = 8.0
+	DefaultAirDeceleration    = 2.0
+	DefaultCoyoteTime         = 0.1
+	DefaultJumpBufferTime     = 0.1
+)
+
+// MovementInput описывает управляющий ввод персонажа на текущем тике
+type MovementInput struct {
+	// Оси движения в диапазоне -1..1
+	Forward, Right, Up float32
+
+	// Jump - прыжок запрошен, Sprint - бег, Crouch - приседание
+	Jump   bool
+	Sprint bool
+	Crouch bool
+
+	// Направления взгляда и вправо, относительно которых задается движение
+	ViewVector, RightVector mgl32.Vec3
+}
+
+// MovementController управляет движением персонажа в мире.
+// Контроллер задает целевую горизонтальную скорость тела и разгоняет или тормозит его к ней
+// с ускорением, зависящим от того, стоит ли тело на земле. Перемещение выполняет PhysicsEngine.
 type MovementController struct {
 	Body      *RigidBody
 	Speed     float32
 	JumpForce float32
 	Flying    bool
+
+	// Скорости бега и шага вприсядку
+	SprintSpeed float32
+	CrouchSpeed float32
+
+	// Скорость всплытия при удержании прыжка в жидкости
+	SwimSpeed float32
+
+	// Скорость подъема по лестнице
+	ClimbSpeed float32
+
+	// Высота тела стоя и вприсядку
+	StandingHeight float32
+	CrouchHeight   float32
+
+	// Ускорения разгона и торможения на земле и в воздухе (м/с²)
+	GroundAcceleration float32
+	GroundDeceleration float32
+	AirAcceleration    float32
+	AirDeceleration    float32
+
+	// CoyoteTime - сколько секунд после схода с опоры прыжок еще разрешен,
+	// JumpBufferTime - сколько секунд запоминается нажатие прыжка до приземления
+	CoyoteTime     float32
+	JumpBufferTime float32
+
+	// Текущее состояние
+	Input     MovementInput
+	Sprinting bool
+	Crouching bool
+	Swimming  bool
+
+	coyoteTimer     float32
+	jumpBufferTimer float32
 }
 
-// NewMovementController создает новый контроллер движения
+// NewMovementController создает новый контроллер движения.
+// speed - скорость ходьбы (м/с), jumpForce - вертикальная скорость прыжка.
 func NewMovementController(body *RigidBody, speed, jumpForce float32) *MovementController {
+	// Трение опоры учитывается контроллером через ускорение, а не гашением скорости в движке
+	body.SelfPropelled = true
+
 	return &MovementController{
-		Body:      body,
-		Speed:     speed,
-		JumpForce: jumpForce,
-		Flying:    false,
+		Body:               body,
+		Speed:              speed,
+		JumpForce:          jumpForce,
+		Flying:             false,
+		SprintSpeed:        speed * DefaultSprintMultiplier,
+		CrouchSpeed:        speed * DefaultCrouchMultiplier,
+		SwimSpeed:          DefaultSwimSpeed,
+		ClimbSpeed:         DefaultClimbSpeed,
+		StandingHeight:     body.Height,
+		CrouchHeight:       body.Height * DefaultCrouchHeightRatio,
+		GroundAcceleration: DefaultGroundAcceleration,
+		GroundDeceleration: DefaultGroundDeceleration,
+		AirAcceleration:    DefaultAirAcceleration,
+		AirDeceleration:    DefaultAirDeceleration,
+		CoyoteTime:         DefaultCoyoteTime,
+		JumpBufferTime:     DefaultJumpBufferTime,
 	}
 }
 
-// Move перемещает персонажа в направлении, указанном форвардом и боковым движением
+// Move возвращает целевую скорость персонажа в направлении, указанном форвардом и боковым движением
 func (m *MovementController) Move(forward, right, up float32, viewVector, rightVector mgl32.Vec3) mgl32.Vec3 {
 	// Получаем горизонтальные компоненты векторов направления (обнуляем Y)
 	flatViewVector := mgl32.Vec3{viewVector.X(), 0, viewVector.Z()}
@@ -46,17 +125,16 @@ func (m *MovementController) Move(forward, right, up float32, viewVector, rightV
 	// Если длина вектора > 0, нормализуем и умножаем на скорость
 	if movement.Len() > 0 {
 		// В движении по ровной поверхности нормализуем только для направления
-		movement = movement.Normalize().Mul(m.Speed)
+		movement = movement.Normalize().Mul(m.currentSpeed())
 	}
 
 	return movement
 }
 
-// Jump заставляет персонажа прыгнуть
+// Jump запрашивает прыжок. Запрос выполняется, если персонаж на земле или только что сошел с нее,
+// либо при приземлении в течение JumpBufferTime.
 func (m *MovementController) Jump() {
-	if m.Body.Grounded {
-		m.Body.Jump()
-	}
+	m.jumpBufferTimer = m.JumpBufferTime
 }
 
 // ToggleFlight переключает режим полета
@@ -65,19 +143,133 @@ func (m *MovementController) ToggleFlight() {
 	m.Body.Flying = m.Flying
 }
 
-// Update обновляет состояние контроллера движения
-func (m *MovementController) Update(forward, right, up float32, viewVector, rightVector mgl32.Vec3) {
-	// Получаем вектор движения
-	movement := m.Move(forward, right, up, viewVector, rightVector)
+// SetInput задает ввод, который будет применен на следующих тиках
+func (m *MovementController) SetInput(input MovementInput) {
+	m.Input = input
+	if input.Jump {
+		m.Jump()
+	}
+}
 
-	// Обновляем позицию тела
-	m.Body.Position = m.Body.Position.Add(movement)
+// Update применяет текущий ввод к скорости тела. Вызывается PhysicsEngine в начале каждого тика.
+// Мир нужен, чтобы проверить, есть ли место выпрямиться после приседания.
+func (m *MovementController) Update(delta float64, world CollisionWorld) {
+	dt := float32(delta)
+	body := m.Body
 
-	// Обновляем историю позиций
-	m.Body.AppendHistory()
+	m.updateCrouch(world)
+	m.Sprinting = m.Input.Sprint && !m.Crouching && m.Input.Forward > 0
+	m.Swimming = body.InFluid && body.Submersion >= SwimSubmersion && !m.Flying
 
-	// Обновляем коллайдер
-	m.Body.UpdateCollider()
+	// Время "койота": прыжок разрешен еще некоторое время после схода с опоры
+	if body.Grounded {
+		m.coyoteTimer = m.CoyoteTime
+	} else if m.coyoteTimer > 0 {
+		m.coyoteTimer -= dt
+	}
+
+	// Целевая скорость и текущая горизонтальная скорость
+	target := m.Move(m.Input.Forward, m.Input.Right, m.Input.Up, m.Input.ViewVector, m.Input.RightVector)
+	if m.Flying {
+		target = target.Mul(body.FlyingSpeedMultipier)
+	}
+	if m.Swimming {
+		target = target.Mul(DefaultSwimSpeedMultiplier)
+	}
+	current := mgl32.Vec3{body.Velocity.X(), 0, body.Velocity.Z()}
+	desired := mgl32.Vec3{target.X(), 0, target.Z()}
+
+	// Выбираем ускорение: разгон или торможение, на земле или в воздухе
+	var accel float32
+	moving := desired.Len() > 0
+	switch {
+	case body.Grounded || m.Flying || m.Swimming:
+		accel = m.GroundDeceleration
+		if moving {
+			accel = m.GroundAcceleration
+		}
+		// Скользкие поверхности уменьшают сцепление
+		if body.Grounded {
+			accel *= traction(body.GroundMaterial)
+		}
+	default:
+		accel = m.AirDeceleration
+		if moving {
+			accel = m.AirAcceleration
+		}
+	}
+
+	horizontal := approach(current, desired, accel*dt)
+	body.Velocity[0] = horizontal.X()
+	body.Velocity[2] = horizontal.Z()
+
+	// В полете вертикальная скорость управляется так же, как горизонтальная
+	if m.Flying {
+		body.Velocity[1] = approachScalar(body.Velocity.Y(), target.Y(), m.GroundAcceleration*dt)
+	}
+
+	// На лестнице движение вперед или прыжок поднимает персонажа, а приседание удерживает на месте
+	if body.Climbing && !m.Flying {
+		switch {
+		case m.Input.Jump || m.Input.Forward > 0:
+			body.Velocity[1] = m.ClimbSpeed
+			body.Grounded = false
+			m.jumpBufferTimer = 0
+			return
+		case m.Crouching && !body.Grounded:
+			holdOnClimbable(body)
+		}
+	}
+
+	// В жидкости прыжок поднимает персонажа к поверхности
+	if body.InFluid && !body.Grounded && !m.Flying {
+		if m.Input.Jump && body.Velocity.Y() < m.SwimSpeed {
+			body.Velocity[1] = approachScalar(body.Velocity.Y(), m.SwimSpeed, m.GroundAcceleration*dt)
+		}
+		m.jumpBufferTimer = 0
+		return
+	}
+
+	// Буфер прыжка: нажатие незадолго до приземления тоже приводит к прыжку
+	if m.jumpBufferTimer > 0 {
+		if !m.Flying && m.coyoteTimer > 0 {
+			body.Velocity[1] = m.JumpForce
+			body.Grounded = false
+			m.coyoteTimer = 0
+			m.jumpBufferTimer = 0
+		} else {
+			m.jumpBufferTimer -= dt
+		}
+	}
+}
+
+// updateCrouch приседает или выпрямляется в соответствии с вводом.
+// Под низким потолком персонаж остается сидеть, пока не появится место.
+func (m *MovementController) updateCrouch(world CollisionWorld) {
+	wantCrouch := m.Input.Crouch && !m.Flying
+	switch {
+	case wantCrouch && !m.Crouching:
+		m.Body.Resize(m.CrouchHeight, world)
+		m.Crouching = true
+	case !wantCrouch && m.Crouching:
+		if m.Body.Resize(m.StandingHeight, world) {
+			m.Crouching = false
+		}
+	}
+
+	// Присевший персонаж не сходит с края блока
+	m.Body.EdgeGuard = m.Crouching
+}
+
+// currentSpeed возвращает скорость с учетом бега и приседания
+func (m *MovementController) currentSpeed() float32 {
+	switch {
+	case m.Crouching:
+		return m.CrouchSpeed
+	case m.Sprinting:
+		return m.SprintSpeed
+	}
+	return m.Speed
 }
 
 // GetPosition возвращает текущую позицию
@@ -91,3 +283,30 @@ func (m *MovementController) SetPosition(pos mgl32.Vec3) {
 	m.Body.AppendHistory()
 	m.Body.UpdateCollider()
 }
+
+// traction возвращает долю сцепления с поверхностью относительно обычного материала (0..1)
+func traction(material Material) float32 {
+	t := material.Friction / DefaultFriction
+	if t > 1 {
+		return 1
+	}
+	return t
+}
+
+// approach приближает вектор к цели не более чем на maxDelta
+func approach(current, target mgl32.Vec3, maxDelta float32) mgl32.Vec3 {
+	diff := target.Sub(current)
+	dist := diff.Len()
+	if dist <= maxDelta || dist == 0 {
+		return target
+	}
+	return current.Add(diff.Mul(maxDelta / dist))
+}
+
+// approachScalar приближает число к цели не более чем на maxDelta
+func approachScalar(current, target, maxDelta float32) float32 {
+	if current < target {
+		return minf(current+maxDelta, target)
+	}
+	return maxf(current-maxDelta, target)
+}
@@ -0,0 +1,930 @@
+package physics
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// boxWorld - мир из неподвижных боксов с материалом по умолчанию
+type boxWorld []Box
+
+func (w boxWorld) CollisionBoxes(region Box) []Box {
+	var result []Box
+	for _, box := range w {
+		if box.Overlaps(region) {
+			result = append(result, box)
+		}
+	}
+	return result
+}
+
+func (w boxWorld) MaterialAt(point mgl32.Vec3) Material {
+	return DefaultMaterial
+}
+
+// approxVec сравнивает векторы с допуском
+func approxVec(a, b mgl32.Vec3) bool {
+	return vecClose(a, b, 1e-4)
+}
+
+// floor - пол толщиной в блок с верхней гранью на высоте 0
+var floor = NewBox(mgl32.Vec3{-50, -1, -50}, mgl32.Vec3{50, 0, 50})
+
+// pileScene создает движок со стопками тел, падающими на пол и друг на друга
+func pileScene(workers int) *PhysicsEngine {
+	p := NewPhysicsEngine(boxWorld{floor})
+	p.Workers = workers
+	for x := 0; x < 8; x++ {
+		for z := 0; z < 4; z++ {
+			for y := 0; y < 3; y++ {
+				// Тела смещены, чтобы стопки расталкивались и соседние острова сливались
+				position := mgl32.Vec3{float32(x)*1.5 + float32(y)*0.3, 1 + float32(y)*1.2, float32(z) * 1.5}
+				body := NewRigidBody(position, 1+float32(y), 0.8, 1)
+				body.Velocity = mgl32.Vec3{float32(z) - 1.5, 0, float32(x%3) - 1}
+				p.Register(body)
+			}
+		}
+	}
+	return p
+}
+
+func TestParallelTickMatchesSerial(t *testing.T) {
+	serial := pileScene(1)
+	parallel := pileScene(8)
+	touched := false
+	for i := 0; i < 200; i++ {
+		serial.Tick(1.0 / 60)
+		parallel.Tick(1.0 / 60)
+		if !bytes.Equal(serial.Snapshot(), parallel.Snapshot()) {
+			t.Fatalf("состояние при Workers=8 разошлось с последовательным на тике %d", i+1)
+		}
+		touched = touched || len(serial.Pairs()) > 0
+	}
+	if !touched {
+		t.Errorf("тела ни разу не соприкоснулись, сцена не проверяет острова")
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	p := pileScene(1)
+	for i := 0; i < 30; i++ {
+		p.Tick(1.0 / 60)
+	}
+
+	// Снимок посреди падения, затем продолжение симуляции
+	snapshot := p.Snapshot()
+	var want [][]byte
+	for i := 0; i < 60; i++ {
+		p.Tick(1.0 / 60)
+		want = append(want, p.Snapshot())
+	}
+
+	// Восстановленный движок должен повторить те же тики бит в бит
+	if err := p.Restore(snapshot); err != nil {
+		t.Fatalf("Restore() = %v", err)
+	}
+	if !bytes.Equal(p.Snapshot(), snapshot) {
+		t.Fatalf("снимок после Restore отличается от восстановленного")
+	}
+	for i := range want {
+		p.Tick(1.0 / 60)
+		if !bytes.Equal(p.Snapshot(), want[i]) {
+			t.Fatalf("повторная симуляция разошлась на тике %d после восстановления", i+1)
+		}
+	}
+}
+
+func TestSnapshotAfterUnregister(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	a := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 1, 1)
+	b := NewRigidBody(mgl32.Vec3{0.5, 0, 0}, 1, 1, 1)
+	c := NewRigidBody(mgl32.Vec3{5, 0, 0}, 1, 1, 1)
+	p.Register(a)
+	p.Register(b)
+	p.Register(c)
+	p.Tick(1.0 / 60)
+	if len(p.Pairs()) == 0 {
+		t.Fatalf("пересекающиеся тела не образовали пару")
+	}
+
+	// Пара с удаленным телом не должна попасть в снимок как пара с телом 0
+	p.Unregister(b)
+	if pairs := p.Pairs(); len(pairs) != 0 {
+		t.Fatalf("Pairs() = %v после удаления тела", pairs)
+	}
+	snapshot := p.Snapshot()
+
+	restored := NewPhysicsEngine(boxWorld{floor})
+	restored.Register(NewRigidBody(mgl32.Vec3{}, 1, 1, 1))
+	restored.Register(NewRigidBody(mgl32.Vec3{}, 1, 1, 1))
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore() = %v", err)
+	}
+	if pairs := restored.Pairs(); len(pairs) != 0 {
+		t.Errorf("восстановлены пары %v", pairs)
+	}
+}
+
+func TestMoveSwept(t *testing.T) {
+	wall := NewBox(mgl32.Vec3{1, 0, -5}, mgl32.Vec3{1.2, 3, 5})
+	ceiling := NewBox(mgl32.Vec3{-5, 2, -5}, mgl32.Vec3{5, 3, 5})
+	tests := []struct {
+		name     string
+		world    boxWorld
+		start    mgl32.Vec3
+		movement mgl32.Vec3
+		want     mgl32.Vec3
+		ground   bool
+		ceiling  bool
+		wallX    bool
+	}{
+		// За один тик тело пролетает больше своей высоты, но не проваливается сквозь пол
+		{"быстрое падение", boxWorld{floor}, mgl32.Vec3{0, 5, 0}, mgl32.Vec3{0, -20, 0}, mgl32.Vec3{0, 0, 0}, true, false, false},
+		{"тонкая стена", boxWorld{floor, wall}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{10, 0, 0}, mgl32.Vec3{0.6, 0, 0}, false, false, true},
+		// Стена останавливает движение только по своей оси, вдоль нее тело скользит
+		{"скольжение вдоль стены", boxWorld{floor, wall}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 0, 1}, mgl32.Vec3{0.6, 0, 1}, false, false, true},
+		{"потолок", boxWorld{floor, ceiling}, mgl32.Vec3{0, 0, 0}, mgl32.Vec3{0, 2, 0}, mgl32.Vec3{0, 1, 0}, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := NewRigidBody(tt.start, 1, 0.8, 1)
+			body.Velocity = tt.movement
+			result := body.Move(tt.movement, tt.world)
+			if !approxVec(body.Position, tt.want) {
+				t.Errorf("Position = %v, ожидалось %v", body.Position, tt.want)
+			}
+			if result.Ground != tt.ground || result.Ceiling != tt.ceiling || result.WallX != tt.wallX {
+				t.Errorf("Ground, Ceiling, WallX = %v, %v, %v, ожидалось %v, %v, %v",
+					result.Ground, result.Ceiling, result.WallX, tt.ground, tt.ceiling, tt.wallX)
+			}
+			if tt.wallX && body.Velocity.X() != 0 {
+				t.Errorf("скорость вдоль X не погашена стеной: %v", body.Velocity)
+			}
+			if tt.wallX && body.Velocity.Z() != tt.movement.Z() {
+				t.Errorf("стена погасила скорость вдоль Z: %v", body.Velocity)
+			}
+		})
+	}
+}
+
+func TestBodySeparation(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	light := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 0.8, 1)
+	heavy := NewRigidBody(mgl32.Vec3{0.4, 0, 0}, 3, 0.8, 1)
+	far := NewRigidBody(mgl32.Vec3{10, 0, 0}, 1, 0.8, 1)
+	for _, body := range []*RigidBody{light, heavy, far} {
+		body.Grounded = true
+		p.Register(body)
+	}
+	p.Tick(1.0 / 60)
+
+	pairs := p.Pairs()
+	if len(pairs) != 1 || pairs[0] != (BodyPair{A: light, B: heavy}) {
+		t.Fatalf("Pairs() = %v, ожидалась одна пара легкого и тяжелого тела", pairs)
+	}
+
+	// Проникновение 0.4 делится обратно пропорционально массам: легкое тело смещается втрое дальше
+	if !approxVec(light.Position, mgl32.Vec3{-0.3, 0, 0}) {
+		t.Errorf("легкое тело в %v, ожидалось (-0.3, 0, 0)", light.Position)
+	}
+	if !approxVec(heavy.Position, mgl32.Vec3{0.5, 0, 0}) {
+		t.Errorf("тяжелое тело в %v, ожидалось (0.5, 0, 0)", heavy.Position)
+	}
+
+	found := p.QueryRegion(NewBox(mgl32.Vec3{9, 0, -1}, mgl32.Vec3{11, 1, 1}))
+	if len(found) != 1 || found[0] != far {
+		t.Errorf("QueryRegion() = %v, ожидалось только дальнее тело", found)
+	}
+}
+
+func TestStepUp(t *testing.T) {
+	tests := []struct {
+		name       string
+		ledge      float32
+		stepHeight float32
+		want       mgl32.Vec3
+	}{
+		{"полублок", 0.5, 0.6, mgl32.Vec3{1, 0.5, 0}},
+		{"полный блок", 1, 0.6, mgl32.Vec3{0.6, 0, 0}},
+		{"без подъема", 0.5, 0, mgl32.Vec3{0.6, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledge := NewBox(mgl32.Vec3{1, 0, -5}, mgl32.Vec3{5, tt.ledge, 5})
+			body := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 0.8, 1.8)
+			body.StepHeight = tt.stepHeight
+			body.Grounded = true
+			result := body.Move(mgl32.Vec3{1, -0.01, 0}, boxWorld{floor, ledge})
+			if !approxVec(body.Position, tt.want) {
+				t.Errorf("Position = %v, ожидалось %v", body.Position, tt.want)
+			}
+			if !result.Ground {
+				t.Errorf("тело потеряло опору")
+			}
+		})
+	}
+}
+
+func TestEdgeGuard(t *testing.T) {
+	platform := NewBox(mgl32.Vec3{-5, -1, -5}, mgl32.Vec3{1, 0, 5})
+	// Стена за пропастью стоит на уровне ног и опорой не считается
+	wall := NewBox(mgl32.Vec3{1.8, 0, -5}, mgl32.Vec3{3, 3, 5})
+	tests := []struct {
+		name     string
+		world    boxWorld
+		guard    bool
+		movement mgl32.Vec3
+		onEdge   bool
+	}{
+		{"с края", boxWorld{platform}, true, mgl32.Vec3{0.8, -0.01, 0}, true},
+		{"к стене за пропастью", boxWorld{platform, wall}, true, mgl32.Vec3{0.8, -0.01, 0}, true},
+		{"без защиты", boxWorld{platform}, false, mgl32.Vec3{0.8, -0.01, 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := NewRigidBody(mgl32.Vec3{0.75, 0, 0}, 1, 0.6, 1.5)
+			body.Grounded = true
+			body.EdgeGuard = tt.guard
+			body.Velocity = tt.movement
+			body.Move(tt.movement, tt.world)
+			if onEdge := body.Collider.Min.X() < platform.Max.X(); onEdge != tt.onEdge {
+				t.Errorf("Collider = %v, тело над опорой: %v, ожидалось %v", *body.Collider, onEdge, tt.onEdge)
+			}
+			if tt.onEdge && body.Velocity.X() != 0 {
+				t.Errorf("скорость к краю не погашена: %v", body.Velocity)
+			}
+		})
+	}
+
+	// Вдоль края тело движется свободно
+	body := NewRigidBody(mgl32.Vec3{0.75, 0, 0}, 1, 0.6, 1.5)
+	body.Grounded = true
+	body.EdgeGuard = true
+	body.Move(mgl32.Vec3{0, -0.01, 1}, boxWorld{platform})
+	if !approxVec(body.Position, mgl32.Vec3{0.75, 0, 1}) {
+		t.Errorf("движение вдоль края: Position = %v", body.Position)
+	}
+}
+
+func TestKinematicPause(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	platform := NewKinematicBody(NewWaypointPath(1, false, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{5, 1, 0}), 2, 0.5)
+	rider := NewRigidBody(mgl32.Vec3{0, 1.5, 0}, 1, 0.5, 1)
+	p.Register(platform)
+	p.Register(rider)
+	for i := 0; i < 30; i++ {
+		p.Tick(1.0 / 60)
+	}
+
+	// Нулевой шаг (пауза) не должен превращать скорость платформы в NaN и сдвигать пассажира
+	position := rider.Position
+	p.Tick(0)
+	if !approxVec(platform.Velocity, mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("скорость платформы на паузе = %v", platform.Velocity)
+	}
+	if rider.Position != position {
+		t.Errorf("пассажир сдвинулся на паузе: %v -> %v", position, rider.Position)
+	}
+}
+
+func TestProjectileHits(t *testing.T) {
+	wall := NewBox(mgl32.Vec3{5, 0, -5}, mgl32.Vec3{5.1, 3, 5})
+	tests := []struct {
+		name     string
+		world    boxWorld
+		target   bool
+		velocity mgl32.Vec3
+		bounces  int
+		normal   mgl32.Vec3
+		// Ожидаемая скорость после попадания
+		after mgl32.Vec3
+	}{
+		// За тик снаряд пролетает 10 м, но стена толщиной 0.1 его останавливает
+		{"тонкая стена", boxWorld{floor, wall}, false, mgl32.Vec3{600, 0, 0}, 0, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{}},
+		{"тело", boxWorld{floor}, true, mgl32.Vec3{600, 0, 0}, 0, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{}},
+		// Отскок отражает скорость от нормали с потерей половины нормальной составляющей
+		{"отскок от стены", boxWorld{floor, wall}, false, mgl32.Vec3{600, 0, 0}, 1, mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{-300, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPhysicsEngine(tt.world)
+			var target *RigidBody
+			if tt.target {
+				target = NewRigidBody(mgl32.Vec3{5, 0, 0}, 1, 0.2, 2)
+				p.Register(target)
+				p.Tick(1.0 / 60)
+			}
+
+			pr := NewProjectile(mgl32.Vec3{0, 1, 0}, tt.velocity, 0.05)
+			pr.Gravity = mgl32.Vec3{}
+			pr.Drag = 0
+			pr.Mass = 0.5
+			pr.Bounces = tt.bounces
+			pr.Bounciness = 0.5
+			var hits []ProjectileHitEvent
+			pr.OnHit = func(e ProjectileHitEvent) { hits = append(hits, e) }
+			p.RegisterProjectile(pr)
+			p.Tick(1.0 / 60)
+
+			if len(hits) != 1 {
+				t.Fatalf("попаданий %d, ожидалось 1; снаряд в %v", len(hits), pr.Position)
+			}
+			hit := hits[0]
+			if hit.Body != target {
+				t.Errorf("Body = %v, ожидалось %v", hit.Body, target)
+			}
+			if !approxVec(hit.Normal, tt.normal) {
+				t.Errorf("Normal = %v, ожидалось %v", hit.Normal, tt.normal)
+			}
+			if hit.Position.X() > 5-pr.Radius+CollisionEpsilon {
+				t.Errorf("снаряд прошел сквозь препятствие: %v", hit.Position)
+			}
+			if !approxVec(pr.Velocity, tt.after) {
+				t.Errorf("скорость после попадания %v, ожидалось %v", pr.Velocity, tt.after)
+			}
+			if tt.target && !approxVec(target.Velocity, mgl32.Vec3{300, 0, 0}) {
+				t.Errorf("тело получило скорость %v, ожидалось (300, 0, 0)", target.Velocity)
+			}
+		})
+	}
+}
+
+func TestProjectileSnapshot(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	archer := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 0.6, 1.8)
+	p.Register(archer)
+	arrow := NewProjectile(mgl32.Vec3{0, 1.5, 0}, mgl32.Vec3{20, 5, 0}, 0.05)
+	arrow.Owner = archer
+	hits := 0
+	arrow.OnHit = func(ProjectileHitEvent) { hits++ }
+	p.RegisterProjectile(arrow)
+	p.Tick(1.0 / 60)
+
+	snapshot := p.Snapshot()
+	// Летящая стрела при восстановлении остается тем же снарядом
+	if err := p.Restore(snapshot); err != nil {
+		t.Fatalf("Restore() = %v", err)
+	}
+	if projectiles := p.Projectiles(); len(projectiles) != 1 || projectiles[0] != arrow {
+		t.Fatalf("Projectiles() = %v, ожидалась та же стрела", projectiles)
+	}
+
+	var want [][]byte
+	for i := 0; i < 90; i++ {
+		p.Tick(1.0 / 60)
+		want = append(want, p.Snapshot())
+	}
+	if hits != 1 || len(p.Projectiles()) != 0 {
+		t.Fatalf("стрела не упала: попаданий %d, снарядов %d", hits, len(p.Projectiles()))
+	}
+
+	// После отката стрела снова летит, а выпущенная после снимка удаляется
+	p.RegisterProjectile(NewProjectile(mgl32.Vec3{0, 5, 0}, mgl32.Vec3{}, 0.05))
+	if err := p.Restore(snapshot); err != nil {
+		t.Fatalf("Restore() = %v", err)
+	}
+	projectiles := p.Projectiles()
+	if len(projectiles) != 1 || projectiles[0].Owner != archer || projectiles[0].Position == arrow.Position {
+		t.Fatalf("Projectiles() = %v после восстановления", projectiles)
+	}
+	for i := range want {
+		p.Tick(1.0 / 60)
+		if !bytes.Equal(p.Snapshot(), want[i]) {
+			t.Fatalf("повторная симуляция разошлась на тике %d после восстановления", i+1)
+		}
+	}
+}
+
+func TestTraceReplay(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	archer := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 0.6, 1.8)
+	target := NewRigidBody(mgl32.Vec3{5, 0, 0}, 1, 0.6, 1.8)
+	p.Register(archer)
+	p.Register(target)
+
+	// Обработчик положения один раз толкает лучника во время тика
+	pushed := false
+	archer.OnPositionUpdated = func(body *RigidBody) {
+		if !pushed && body.Position.Y() < 0.01 {
+			pushed = true
+			body.ApplyImpulseAt(mgl32.Vec3{0, 0, 3}, body.Center())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := p.StartTrace(&buf); err != nil {
+		t.Fatalf("StartTrace() = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		p.Tick(1.0 / 60)
+	}
+
+	// Стрела, выпущенная между тиками, толкает цель, а обработчик попадания подбрасывает ее
+	arrow := NewProjectile(mgl32.Vec3{0.5, 1, 0}, mgl32.Vec3{60, 0, 0}, 0.05)
+	arrow.Owner = archer
+	arrow.Mass = 0.5
+	hits := 0
+	arrow.OnHit = func(e ProjectileHitEvent) {
+		hits++
+		e.Body.ApplyImpulseAt(mgl32.Vec3{0, 4, 0}, e.Body.Center())
+	}
+	p.RegisterProjectile(arrow)
+	for i := 0; i < 30; i++ {
+		p.Tick(1.0 / 60)
+	}
+	if hits != 1 || !pushed {
+		t.Fatalf("попаданий %d, толчок %v: сцена не проверяет изменения обработчиков", hits, pushed)
+	}
+
+	// Новое тело прерывает запись, но записанные тики остаются в журнале
+	p.Register(NewRigidBody(mgl32.Vec3{-5, 0, 0}, 1, 0.6, 1.8))
+	p.Tick(1.0 / 60)
+	if p.TraceError() == nil {
+		t.Fatalf("TraceError() = nil после изменения состава тел")
+	}
+	if err := p.StopTrace(); err == nil {
+		t.Fatalf("StopTrace() = nil после изменения состава тел")
+	}
+
+	data := buf.Bytes()
+	trace, err := ReadTrace(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadTrace() = %v", err)
+	}
+	if len(trace.Ticks) != 40 {
+		t.Fatalf("в журнале %d тиков, ожидалось 40", len(trace.Ticks))
+	}
+	divergence, err := trace.Replay(boxWorld{floor}, 0)
+	if err != nil {
+		t.Fatalf("Replay() = %v", err)
+	}
+	if divergence != nil {
+		t.Fatalf("повтор разошелся с записью: %v", divergence)
+	}
+
+	// Выпущенная стрела записана перед тиком, на котором она появилась
+	if projectiles := trace.Ticks[10].Changes.Projectiles; len(projectiles) != 1 || projectiles[0].Owner != trace.Bodies[0] {
+		t.Errorf("снаряды перед тиком 11: %v, ожидалась стрела лучника", projectiles)
+	}
+
+	// Без изменений обработчиков повтор расходится, значит они действительно записаны
+	for _, changes := range []func(*TraceTick) *TraceChanges{
+		func(tick *TraceTick) *TraceChanges { return &tick.PositionHandlerChanges },
+		func(tick *TraceTick) *TraceChanges { return &tick.EventHandlerChanges },
+	} {
+		trace, err := ReadTrace(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("ReadTrace() = %v", err)
+		}
+		for i := range trace.Ticks {
+			*changes(&trace.Ticks[i]) = TraceChanges{}
+		}
+		if divergence, err := trace.Replay(boxWorld{floor}, 1e-4); err != nil || divergence == nil {
+			t.Errorf("Replay() без изменений обработчиков = %v, %v, ожидалось расхождение", divergence, err)
+		}
+	}
+}
+
+func TestBodyEffect(t *testing.T) {
+	// Тело занимает бокс (-0.5, 0, -0.5)..(0.5, 2, 0.5)
+	all := NewBox(mgl32.Vec3{-5, -5, -5}, mgl32.Vec3{5, 5, 5})
+	lower := NewBox(mgl32.Vec3{-5, -5, -5}, mgl32.Vec3{5, 1, 5})
+	east := NewBox(mgl32.Vec3{0, -5, -5}, mgl32.Vec3{5, 5, 5})
+	above := NewBox(mgl32.Vec3{-5, 1, -5}, mgl32.Vec3{5, 5, 5})
+	gravity := mgl32.Vec3{0, -DefaultGravity, 0}
+
+	tests := []struct {
+		name     string
+		grounded bool
+		flying   bool
+		volumes  []*ForceVolume
+		want     volumeEffect
+	}{
+		{"без объемов", false, false, nil, volumeEffect{acceleration: gravity}},
+		{"замена", false, false, []*ForceVolume{
+			{Box: all, Blend: VolumeOverride, Acceleration: mgl32.Vec3{0, -2, 0}},
+		}, volumeEffect{acceleration: mgl32.Vec3{0, -2, 0}}},
+		{"сложение с ветром", false, false, []*ForceVolume{
+			{Box: all, Blend: VolumeAdd, Acceleration: mgl32.Vec3{8, 0, 0}},
+		}, volumeEffect{acceleration: mgl32.Vec3{8, -DefaultGravity, 0}}},
+		{"умножение", false, false, []*ForceVolume{
+			{Box: all, Blend: VolumeScale, Scale: 0.5},
+		}, volumeEffect{acceleration: mgl32.Vec3{0, -DefaultGravity / 2, 0}}},
+		// Половина тела в зоне замены получает среднее между гравитацией тела и зоны
+		{"тело наполовину в замене", false, false, []*ForceVolume{
+			{Box: lower, Blend: VolumeOverride, Acceleration: mgl32.Vec3{0, -2, 0}},
+		}, volumeEffect{acceleration: mgl32.Vec3{0, -7, 0}}},
+		// Объемы применяются по возрастанию приоритета, а не по порядку регистрации
+		{"приоритет", false, false, []*ForceVolume{
+			{Box: all, Blend: VolumeScale, Scale: 0.5, Priority: 1},
+			{Box: all, Blend: VolumeOverride, Acceleration: mgl32.Vec3{0, -2, 0}},
+		}, volumeEffect{acceleration: mgl32.Vec3{0, -1, 0}}},
+		{"равный приоритет по порядку регистрации", false, false, []*ForceVolume{
+			{Box: all, Blend: VolumeScale, Scale: 0.5},
+			{Box: all, Blend: VolumeOverride, Acceleration: mgl32.Vec3{0, -2, 0}},
+		}, volumeEffect{acceleration: mgl32.Vec3{0, -2, 0}}},
+		{"перенос стоящего тела", true, false, []*ForceVolume{
+			{Box: all, Blend: VolumeAdd, Carry: mgl32.Vec3{0, 0, 2}},
+		}, volumeEffect{acceleration: gravity, carry: mgl32.Vec3{0, 0, 2}}},
+		{"перенос тела в воздухе", false, false, []*ForceVolume{
+			{Box: all, Blend: VolumeAdd, Carry: mgl32.Vec3{0, 0, 2}},
+		}, volumeEffect{acceleration: gravity}},
+		// Запуск пропорционален доле опоры, а не доле тела в объеме
+		{"запуск с половины опоры", true, false, []*ForceVolume{
+			{Box: east, Blend: VolumeAdd, Launch: mgl32.Vec3{0, 9, 0}},
+		}, volumeEffect{acceleration: gravity, launch: mgl32.Vec3{0, 4.5, 0}}},
+		{"запуск выше опоры", true, false, []*ForceVolume{
+			{Box: above, Blend: VolumeAdd, Launch: mgl32.Vec3{0, 9, 0}},
+		}, volumeEffect{acceleration: gravity}},
+		{"полет", true, true, []*ForceVolume{
+			{Box: all, Blend: VolumeAdd, Acceleration: mgl32.Vec3{8, 0, 0}, Carry: mgl32.Vec3{0, 0, 2}},
+		}, volumeEffect{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPhysicsEngine(nil)
+			for _, v := range tt.volumes {
+				p.RegisterForceVolume(v)
+			}
+			body := NewRigidBody(mgl32.Vec3{}, 1, 1, 2)
+			body.Grounded = tt.grounded
+			body.Flying = tt.flying
+			body.UpdateCollider()
+
+			got := bodyEffect(body, p.sortedVolumes())
+			if !approxVec(got.acceleration, tt.want.acceleration) {
+				t.Errorf("acceleration = %v, ожидалось %v", got.acceleration, tt.want.acceleration)
+			}
+			if !approxVec(got.carry, tt.want.carry) {
+				t.Errorf("carry = %v, ожидалось %v", got.carry, tt.want.carry)
+			}
+			if !approxVec(got.launch, tt.want.launch) {
+				t.Errorf("launch = %v, ожидалось %v", got.launch, tt.want.launch)
+			}
+		})
+	}
+}
+
+func TestHorizontalField(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	wind := NewForceVolume("ветер", NewBox(mgl32.Vec3{-50, 0, -50}, mgl32.Vec3{50, 50, 50}), VolumeAdd)
+	wind.Acceleration = mgl32.Vec3{0, 0, 30}
+	p.RegisterForceVolume(wind)
+
+	// Ветер не меняет низ: тело с гравитацией стоит на полу
+	standing := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 0.6, 1.8)
+	p.Register(standing)
+	// Без гравитации ветер разгоняет тело быстрее предельной скорости падения
+	floating := NewRigidBody(mgl32.Vec3{0, 20, 0}, 1, 0.6, 1.8)
+	floating.Gravity = mgl32.Vec3{}
+	floating.AirDrag = 0
+	p.Register(floating)
+
+	for i := 0; i < 60; i++ {
+		p.Tick(1.0 / 60)
+	}
+	if !standing.Grounded || standing.Position.Y() != 0 {
+		t.Errorf("тело не стоит на полу: Grounded = %v, Position = %v", standing.Grounded, standing.Position)
+	}
+	if !approxVec(floating.Velocity, mgl32.Vec3{0, 0, 30}) {
+		t.Errorf("Velocity = %v, ожидалось (0, 0, 30)", floating.Velocity)
+	}
+}
+
+// sleepingBody создает движок с телом, уснувшим на полу
+func sleepingBody(t *testing.T) (*PhysicsEngine, *RigidBody) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	p.SleepTicks = 10
+	body := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 1, 1)
+	p.Register(body)
+	for i := 0; i < 60 && !body.Sleeping; i++ {
+		p.Tick(1.0 / 60)
+	}
+	if !body.Sleeping {
+		t.Fatalf("тело на полу не уснуло")
+	}
+	return p, body
+}
+
+func TestFallAsleep(t *testing.T) {
+	p := NewPhysicsEngine(boxWorld{floor})
+	p.SleepTicks = 10
+	body := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 1, 1)
+	body.Velocity = mgl32.Vec3{1, 0, 0}
+	p.Register(body)
+
+	ticks := 0
+	for ; ticks < 300 && !body.Sleeping; ticks++ {
+		p.Tick(1.0 / 60)
+	}
+	if !body.Sleeping {
+		t.Fatalf("тело не уснуло за %d тиков", ticks)
+	}
+	// Тело сначала тормозится трением, затем должно покоиться SleepTicks тиков
+	if ticks <= p.SleepTicks {
+		t.Errorf("тело уснуло через %d тиков, раньше SleepTicks = %d", ticks, p.SleepTicks)
+	}
+	if body.Velocity != (mgl32.Vec3{}) {
+		t.Errorf("у спящего тела скорость %v", body.Velocity)
+	}
+
+	// Спящее тело не обрабатывается и не сдвигается
+	position := body.Position
+	p.Tick(1.0 / 60)
+	if !body.Sleeping || body.Position != position {
+		t.Errorf("спящее тело сдвинулось: %v -> %v", position, body.Position)
+	}
+}
+
+func TestIslandSleep(t *testing.T) {
+	// Ящик на широком основании скользит и останавливается трением. Основание покоится с первых тиков,
+	// но засыпает только вместе с ящиком, потому что они образуют один остров
+	p := NewPhysicsEngine(boxWorld{floor})
+	p.SleepTicks = 10
+	base := NewRigidBody(mgl32.Vec3{0, 0, 0}, 4, 4, 1)
+	crate := NewRigidBody(mgl32.Vec3{-1, 1, 0}, 1, 1, 1)
+	crate.Velocity = mgl32.Vec3{2, 0, 0}
+	p.Register(base)
+	p.Register(crate)
+
+	ticks := 0
+	for ; ticks < 300 && !base.Sleeping; ticks++ {
+		p.Tick(1.0 / 60)
+		if base.Sleeping != crate.Sleeping {
+			t.Fatalf("на тике %d спит только одно тело: основание %v, ящик %v", ticks+1, base.Sleeping, crate.Sleeping)
+		}
+	}
+	if !base.Sleeping {
+		t.Fatalf("остров не уснул за %d тиков", ticks)
+	}
+	if ticks <= 2*p.SleepTicks {
+		t.Errorf("остров уснул через %d тиков, пока ящик еще скользил", ticks)
+	}
+}
+
+func TestWake(t *testing.T) {
+	tests := []struct {
+		name string
+		// disturb меняет сцену со спящим телом перед тиком
+		disturb func(p *PhysicsEngine, body *RigidBody)
+		awake   bool
+	}{
+		{"без изменений", func(p *PhysicsEngine, body *RigidBody) {}, false},
+		{"импульс", func(p *PhysicsEngine, body *RigidBody) {
+			body.ApplyImpulseAt(mgl32.Vec3{2, 0, 0}, body.Center())
+		}, true},
+		{"сила", func(p *PhysicsEngine, body *RigidBody) {
+			body.ApplyForceAt(mgl32.Vec3{0, 20, 0}, body.Center())
+		}, true},
+		{"потеря опоры", func(p *PhysicsEngine, body *RigidBody) { body.Grounded = false }, true},
+		{"область рядом", func(p *PhysicsEngine, body *RigidBody) {
+			p.WakeRegion(NewBox(mgl32.Vec3{0.5, 0, 0}, mgl32.Vec3{1.5, 1, 1}))
+		}, true},
+		{"область вдали", func(p *PhysicsEngine, body *RigidBody) {
+			p.WakeRegion(NewBox(mgl32.Vec3{5, 0, 0}, mgl32.Vec3{6, 1, 1}))
+		}, false},
+		// Упавшее сверху тело входит в спящее и будит его
+		{"падение сверху", func(p *PhysicsEngine, body *RigidBody) {
+			falling := NewRigidBody(mgl32.Vec3{0, 1.1, 0}, 1, 1, 1)
+			falling.Velocity = mgl32.Vec3{0, DefaultTerminalVelocity, 0}
+			p.Register(falling)
+		}, true},
+		// Неподвижное кинематическое тело, касающееся спящего, его не будит
+		{"неподвижная платформа", func(p *PhysicsEngine, body *RigidBody) {
+			platform := NewRigidBody(mgl32.Vec3{0.9, 0, 0}, 1, 1, 1)
+			platform.Kinematic = true
+			p.Register(platform)
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, body := sleepingBody(t)
+			tt.disturb(p, body)
+			p.Tick(1.0 / 60)
+			if body.Sleeping == tt.awake {
+				t.Errorf("Sleeping = %v, ожидалось пробуждение: %v", body.Sleeping, tt.awake)
+			}
+		})
+	}
+}
+
+func TestWakeNeighbours(t *testing.T) {
+	// Два ящика, уснувшие один на другом, просыпаются вместе от толчка в нижний
+	p := NewPhysicsEngine(boxWorld{floor})
+	p.SleepTicks = 10
+	bottom := NewRigidBody(mgl32.Vec3{0, 0, 0}, 1, 1, 1)
+	top := NewRigidBody(mgl32.Vec3{0, 1, 0}, 1, 1, 1)
+	p.Register(bottom)
+	p.Register(top)
+	for i := 0; i < 120 && !(bottom.Sleeping && top.Sleeping); i++ {
+		p.Tick(1.0 / 60)
+	}
+	if !bottom.Sleeping || !top.Sleeping {
+		t.Fatalf("ящики не уснули: %v, %v", bottom.Sleeping, top.Sleeping)
+	}
+
+	p.Wake(bottom)
+	if bottom.Sleeping || top.Sleeping {
+		t.Errorf("после Wake спят: нижний %v, верхний %v", bottom.Sleeping, top.Sleeping)
+	}
+}
+
+// blastWorld - мир из блоков-кубов с заданной стойкостью к взрыву
+type blastWorld map[[3]int]float32
+
+func (w blastWorld) CollisionBoxes(region Box) []Box {
+	var result []Box
+	for block := range w {
+		box := NewBox(mgl32.Vec3{float32(block[0]), float32(block[1]), float32(block[2])},
+			mgl32.Vec3{float32(block[0] + 1), float32(block[1] + 1), float32(block[2] + 1)})
+		if box.Overlaps(region) {
+			result = append(result, box)
+		}
+	}
+	return result
+}
+
+func (w blastWorld) MaterialAt(point mgl32.Vec3) Material {
+	return DefaultMaterial
+}
+
+func (w blastWorld) BlastResistance(block [3]int) (float32, bool) {
+	resistance, ok := w[block]
+	return resistance, ok
+}
+
+func (w blastWorld) DestroyBlock(block [3]int) {
+	delete(w, block)
+}
+
+func TestExplosionBlocks(t *testing.T) {
+	near, far := [3]int{2, 0, 0}, [3]int{-3, 0, 0}
+	tests := []struct {
+		name       string
+		resistance float32
+		power      float32
+		// Ожидается ли разрушение ближнего блока на расстоянии 1.5 и дальнего на расстоянии 2.5
+		near, far bool
+	}{
+		{"мягкие блоки", 0.5, 3, true, true},
+		// Луч ослабевает с расстоянием и не доходит до дальнего блока
+		{"слабый взрыв", 0.5, 2, true, false},
+		{"прочные блоки", 10, 3, false, false},
+		{"неразрушимые блоки", -1, 100, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			world := blastWorld{near: tt.resistance, far: tt.resistance}
+			p := NewPhysicsEngine(world)
+			event := p.Explode(mgl32.Vec3{0.5, 0.5, 0.5}, tt.power)
+
+			_, nearLeft := world[near]
+			_, farLeft := world[far]
+			if nearLeft == tt.near || farLeft == tt.far {
+				t.Errorf("разрушены ближний %v, дальний %v, ожидалось %v, %v", !nearLeft, !farLeft, tt.near, tt.far)
+			}
+			if len(event.Blocks) != 2-len(world) {
+				t.Errorf("Blocks = %v, а разрушено %d блоков", event.Blocks, 2-len(world))
+			}
+		})
+	}
+}
+
+func TestExplosionShelter(t *testing.T) {
+	// Неразрушимая стена 3x3 закрывает тело от взрыва
+	world := blastWorld{}
+	for y := -1; y <= 1; y++ {
+		for z := -1; z <= 1; z++ {
+			world[[3]int{2, y, z}] = -1
+		}
+	}
+	center := mgl32.Vec3{0.5, 0.5, 0.5}
+	p := NewPhysicsEngine(world)
+
+	tests := []struct {
+		name     string
+		collider Box
+		min, max float32
+	}{
+		{"открытое тело", NewBox(mgl32.Vec3{-3, 0, 0}, mgl32.Vec3{-2, 1, 1}), 1, 1},
+		{"тело за стеной", NewBox(mgl32.Vec3{4, 0, 0}, mgl32.Vec3{5, 1, 1}), 0, 0},
+		{"тело наполовину за стеной", NewBox(mgl32.Vec3{4, 0, 3}, mgl32.Vec3{5, 1, 6}), 0.1, 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if exposure := p.exposure(center, tt.collider); exposure < tt.min || exposure > tt.max {
+				t.Errorf("exposure = %v, ожидалось от %v до %v", exposure, tt.min, tt.max)
+			}
+		})
+	}
+
+	hidden := NewRigidBody(mgl32.Vec3{4.5, 0, 0.5}, 1, 1, 1)
+	open := NewRigidBody(mgl32.Vec3{-2.5, 0, 0.5}, 1, 1, 1)
+	p.Register(hidden)
+	p.Register(open)
+	p.Tick(1.0 / 60)
+	hidden.Velocity, open.Velocity = mgl32.Vec3{}, mgl32.Vec3{}
+
+	event := p.Explode(center, 4)
+	if len(event.Bodies) != 1 || event.Bodies[0] != open {
+		t.Errorf("Bodies = %v, ожидалось только открытое тело", event.Bodies)
+	}
+	if hidden.Velocity != (mgl32.Vec3{}) {
+		t.Errorf("тело за стеной получило скорость %v", hidden.Velocity)
+	}
+	if len(event.Blocks) != 0 || len(world) != 9 {
+		t.Errorf("разрушены неразрушимые блоки: %v", event.Blocks)
+	}
+}
+
+func TestExplosionImpulse(t *testing.T) {
+	// Без мира тела полностью открыты и импульс зависит только от расстояния до центра коллайдера
+	p := NewPhysicsEngine(nil)
+	var bodies []*RigidBody
+	for _, x := range []float32{0, 2, 4, 8} {
+		body := NewRigidBody(mgl32.Vec3{x, 0, 0}, 2, 1, 1)
+		body.UpdateCollider()
+		p.Register(body)
+		bodies = append(bodies, body)
+	}
+	platform := NewRigidBody(mgl32.Vec3{-2, 0, 0}, 2, 1, 1)
+	platform.Kinematic = true
+	platform.UpdateCollider()
+	p.Register(platform)
+
+	// Радиус взрыва мощности 4 равен 8: импульс 100·4·(1 - d/8) делится на массу 2
+	event := p.Explode(mgl32.Vec3{0, 0.5, 0}, 4)
+	want := []mgl32.Vec3{{0, 200, 0}, {150, 0, 0}, {100, 0, 0}, {}}
+	for i, body := range bodies {
+		if !approxVec(body.Velocity, want[i]) {
+			t.Errorf("тело %d: Velocity = %v, ожидалось %v", i, body.Velocity, want[i])
+		}
+	}
+	if platform.Velocity != (mgl32.Vec3{}) {
+		t.Errorf("кинематическое тело получило скорость %v", platform.Velocity)
+	}
+	if len(event.Bodies) != 3 {
+		t.Errorf("Bodies = %d тел, ожидалось 3", len(event.Bodies))
+	}
+}
+
+// eventScene роняет ящик сквозь датчик на пол и второй ящик на первый.
+// Возвращает события в порядке вызова обработчиков.
+func eventScene(workers int) []string {
+	p := NewPhysicsEngine(boxWorld{floor})
+	p.Workers = workers
+	bodies := map[*RigidBody]string{}
+	lower := NewRigidBody(mgl32.Vec3{0, 5, 0}, 1, 1, 1)
+	upper := NewRigidBody(mgl32.Vec3{0.2, 8, 0}, 1, 0.6, 0.6)
+	bodies[lower], bodies[upper] = "нижний", "верхний"
+	p.Register(lower)
+	p.Register(upper)
+	p.RegisterSensor(NewSensor("плита", NewBox(mgl32.Vec3{-1, 3, -1}, mgl32.Vec3{1, 4, 1})))
+
+	var log []string
+	p.OnBlockHit = func(e BlockHitEvent) {
+		log = append(log, fmt.Sprintf("удар %s о грань %d", bodies[e.Body], e.Face))
+	}
+	p.OnBodyHit = func(e BodyHitEvent) { log = append(log, fmt.Sprintf("удар %s о %s", bodies[e.A], bodies[e.B])) }
+	p.OnLanded = func(e LandedEvent) { log = append(log, "приземление "+bodies[e.Body]) }
+	p.OnSensorEnter = func(e SensorEvent) { log = append(log, "вход "+bodies[e.Body]) }
+	p.OnSensorExit = func(e SensorEvent) { log = append(log, "выход "+bodies[e.Body]) }
+
+	for i := 0; i < 240; i++ {
+		p.Tick(1.0 / 60)
+	}
+	return log
+}
+
+func TestContactEvents(t *testing.T) {
+	// Каждое событие возникает один раз в начале касания, хотя тела лежат друг на друге до конца сцены
+	want := []string{
+		"вход нижний",
+		"выход нижний",
+		"вход верхний",
+		fmt.Sprintf("удар нижний о грань %d", FaceUp),
+		"приземление нижний",
+		"выход верхний",
+		"удар нижний о верхний",
+	}
+	got := eventScene(1)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("события:\n%s\nожидалось:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Порядок событий не зависит от числа горутин
+	if parallel := eventScene(8); strings.Join(parallel, "\n") != strings.Join(got, "\n") {
+		t.Errorf("события при Workers=8:\n%s", strings.Join(parallel, "\n"))
+	}
+}
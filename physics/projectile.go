@@ -0,0 +1,217 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/geometry"
+)
+
+const (
+	// Константы снарядов
+	DefaultProjectileGravity = 20.0
+	DefaultProjectileDrag    = 0.2
+
+	// Скорость, ниже которой отскочивший снаряд останавливается
+	MinProjectileSpeed = 0.5
+)
+
+// Projectile - легкое тело для стрел, снежков и брошенных предметов.
+// Перемещение снаряда проверяется непрерывно (swept) против форм блоков и тел,
+// поэтому быстрые снаряды не пролетают сквозь препятствия.
+type Projectile struct {
+	Position mgl32.Vec3
+	Velocity mgl32.Vec3
+
+	// Половина размера бокса снаряда (0 - точка)
+	Radius float32
+
+	// Масса снаряда; при попадании в тело передает ему импульс (0 - не передает)
+	Mass float32
+
+	// Ускорение свободного падения (м/с²) и скорость затухания скорости (1/с).
+	// Объемы сил меняют гравитацию снаряда так же, как гравитацию тел
+	Gravity mgl32.Vec3
+	Drag    float32
+
+	// Доля скорости, сохраняемая при отскоке, и оставшееся число отскоков.
+	// Снаряд без отскоков останавливается при первом попадании.
+	Bounciness float32
+	Bounces    int
+
+	// Тело, выпустившее снаряд; снаряд с ним не сталкивается
+	Owner *RigidBody
+
+	// Active сбрасывается, когда снаряд остановился
+	Active bool
+
+	// Обработчик попадания, вызываемый в конце тика
+	OnHit func(ProjectileHitEvent)
+
+	// Номер снаряда в движке, по которому его находят при восстановлении снимка
+	id uint64
+}
+
+// ProjectileHitEvent описывает попадание снаряда в блок или тело
+type ProjectileHitEvent struct {
+	Projectile *Projectile
+
+	// Тело, в которое попал снаряд (nil при попадании в блок)
+	Body *RigidBody
+
+	// Координаты блока и его форма при попадании в блок
+	Block [3]int
+	Box   Box
+
+	// Точка попадания (центр снаряда в момент касания) и нормаль поверхности
+	Position mgl32.Vec3
+	Normal   mgl32.Vec3
+
+	// Скорость снаряда в момент попадания
+	Velocity mgl32.Vec3
+}
+
+// NewProjectile создает снаряд с параметрами по умолчанию
+func NewProjectile(position, velocity mgl32.Vec3, radius float32) *Projectile {
+	return &Projectile{
+		Position: position,
+		Velocity: velocity,
+		Radius:   radius,
+		Gravity:  mgl32.Vec3{0, -DefaultProjectileGravity, 0},
+		Drag:     DefaultProjectileDrag,
+		Active:   true,
+	}
+}
+
+// Bounds возвращает бокс снаряда
+func (pr *Projectile) Bounds() Box {
+	return geometry.BoxAround(pr.Position, mgl32.Vec3{pr.Radius, pr.Radius, pr.Radius})
+}
+
+// RegisterProjectile добавляет снаряд в движок. Остановившиеся снаряды удаляются автоматически.
+func (p *PhysicsEngine) RegisterProjectile(projectile *Projectile) {
+	for _, pr := range p.projectiles {
+		if pr == projectile {
+			return
+		}
+	}
+	p.projectileSerial++
+	projectile.id = p.projectileSerial
+	p.projectiles = append(p.projectiles, projectile)
+}
+
+// UnregisterProjectile удаляет снаряд из движка
+func (p *PhysicsEngine) UnregisterProjectile(projectile *Projectile) {
+	for i, pr := range p.projectiles {
+		if pr == projectile {
+			p.projectiles = append(p.projectiles[:i], p.projectiles[i+1:]...)
+			return
+		}
+	}
+}
+
+// Projectiles возвращает активные снаряды
+func (p *PhysicsEngine) Projectiles() []*Projectile {
+	projectiles := make([]*Projectile, len(p.projectiles))
+	copy(projectiles, p.projectiles)
+	return projectiles
+}
+
+// updateProjectiles перемещает снаряды и удаляет остановившиеся
+func (p *PhysicsEngine) updateProjectiles(delta float64) {
+	active := p.projectiles[:0]
+	for _, pr := range p.projectiles {
+		if pr.Active {
+			p.updateProjectile(pr, delta)
+		}
+		if pr.Active {
+			active = append(active, pr)
+		}
+	}
+	// Обнуляем хвост, чтобы не удерживать удаленные снаряды
+	for i := len(active); i < len(p.projectiles); i++ {
+		p.projectiles[i] = nil
+	}
+	p.projectiles = active
+}
+
+// updateProjectile интегрирует скорость снаряда и проверяет попадание на пути за тик
+func (p *PhysicsEngine) updateProjectile(pr *Projectile, delta float64) {
+	dt := float32(delta)
+	pr.Velocity = pr.Velocity.Add(fieldAt(pr.Position, pr.Gravity, p.activeVolumes).Mul(dt))
+	pr.Velocity = pr.Velocity.Mul(float32(math.Exp(-float64(pr.Drag) * delta)))
+
+	movement := pr.Velocity.Mul(dt)
+	hit, t, ok := p.sweepProjectile(pr, movement)
+	if !ok {
+		pr.Position = pr.Position.Add(movement)
+		return
+	}
+
+	pr.Position = pr.Position.Add(movement.Mul(t))
+	hit.Position = pr.Position
+	hit.Velocity = pr.Velocity
+
+	// Передаем импульс телу в точке попадания: попадание сбоку от центра закручивает вращающееся тело
+	if hit.Body != nil && pr.Mass > 0 {
+		hit.Body.ApplyImpulseAt(pr.Velocity.Mul(pr.Mass), hit.Position)
+	}
+
+	// Отскок с отражением скорости от поверхности, иначе снаряд застревает
+	if pr.Bounces > 0 && pr.Bounciness > 0 {
+		pr.Bounces--
+		normalSpeed := pr.Velocity.Dot(hit.Normal)
+		pr.Velocity = pr.Velocity.Sub(hit.Normal.Mul((1 + pr.Bounciness) * normalSpeed))
+		// Отступаем от поверхности, чтобы следующий тик не начался в касании
+		pr.Position = pr.Position.Add(hit.Normal.Mul(CollisionEpsilon))
+		if pr.Velocity.Len() < MinProjectileSpeed {
+			pr.Active = false
+		}
+	} else {
+		pr.Velocity = mgl32.Vec3{}
+		pr.Active = false
+	}
+
+	p.emit(hit)
+}
+
+// sweepProjectile ищет первое препятствие на пути снаряда среди форм мира и тел.
+// Возвращает попадание и долю смещения до него.
+func (p *PhysicsEngine) sweepProjectile(pr *Projectile, movement mgl32.Vec3) (ProjectileHitEvent, float32, bool) {
+	bounds := pr.Bounds()
+	region := bounds.Stretch(movement)
+
+	best := float32(2)
+	var hit ProjectileHitEvent
+
+	if p.world != nil {
+		for _, box := range p.world.CollisionBoxes(region) {
+			t, normal, ok := geometry.SweepBox(bounds, movement, box)
+			if ok && t < best {
+				best = t
+				hit = ProjectileHitEvent{Projectile: pr, Block: blockOf(box), Box: box, Normal: normal}
+			}
+		}
+	}
+
+	for _, body := range p.QueryRegion(region) {
+		if body == pr.Owner || body.Collider == nil {
+			continue
+		}
+		t, normal, ok := geometry.SweepBox(bounds, movement, *body.Collider)
+		if ok && t < best {
+			best = t
+			hit = ProjectileHitEvent{Projectile: pr, Body: body, Box: *body.Collider, Normal: normal}
+		}
+	}
+
+	if best > 1 {
+		return ProjectileHitEvent{}, 0, false
+	}
+
+	// Снаряд начал тик внутри препятствия: нормаль направлена против движения
+	if hit.Normal.Len() == 0 && movement.Len() > 0 {
+		hit.Normal = movement.Normalize().Mul(-1)
+	}
+	return hit, best, true
+}
@@ -14,6 +14,10 @@ const (
 	DefaultFlyingSpeedMultipier    = 2.0
 	DefaultPositionHistoryLength   = 20
 	DefaultTerminalVelocity        = -10.0
+	DefaultCharacterStepHeight     = 0.6
+
+	// Глубина под ногами, на которой определяется материал опоры
+	groundProbeDepth = 0.05
 )
 
 // RigidBody содержит физическое состояние сущности
@@ -30,14 +34,80 @@ type RigidBody struct {
 	Flying            bool
 	Grounded          bool
 
-	// Настраиваемые параметры физики
+	// Дополнительное смещение, применяемое движком на следующем тике с учетом коллизий
+	Movement mgl32.Vec3
+
+	// Горизонтальной скоростью тела управляет контроллер движения, движок не гасит ее трением
+	SelfPropelled bool
+
+	// Материал поверхности, на которой стоит тело
+	GroundMaterial Material
+
+	// Высота, пролетенная с момента начала падения (сбрасывается при приземлении и подъеме)
+	FallDistance float32
+
+	// Грани, с которыми тело соприкасалось на прошлом тике (для событий начала контакта)
+	contactFaces uint8
+
+	// Сумма сил, приложенных на последнем тике (для журнала симуляции)
+	appliedForce mgl32.Vec3
+
+	// Настраиваемые параметры физики. Gravity - вектор ускорения свободного падения (м/с²),
+	// объемы сил могут изменить его внутри своей области. Тело падает вдоль -Y, горизонтальные
+	// составляющие Gravity действуют как ветер (см. ForceVolume)
 	JumpSpeed               float32
-	Gravity                 float32
+	Gravity                 mgl32.Vec3
 	PenetrationEpsilonSmall float32
 	PenetrationEpsilonBig   float32
 	AirMovementSuppression  float32
 	FlyingSpeedMultipier    float32
 	PositionHistoryLength   int
+	AirDrag                 float32
+
+	// Максимальная высота уступа, на который тело поднимается без прыжка (0 - не поднимается)
+	StepHeight float32
+
+	// Стоящее тело не сходит с края опоры (например, при приседании)
+	EdgeGuard bool
+
+	// InFluid - тело пересекается с жидкостью, Submersion - доля погруженного объема (0..1)
+	InFluid    bool
+	Submersion float32
+
+	// Доля веса, компенсируемая выталкивающей силой воды при полном погружении
+	Buoyancy float32
+
+	// Climbing - тело касается блока, по которому можно карабкаться;
+	// MaxClimbFall - максимальная скорость падения при этом
+	Climbing     bool
+	MaxClimbFall float32
+
+	// Kinematic - тело движется по траектории Path, не подчиняясь гравитации и коллизиям с миром
+	Kinematic bool
+	Path      Path
+	// Время движения по траектории
+	pathTime float64
+
+	// Кинематическое тело, на котором стоит это тело
+	Platform *RigidBody
+
+	// Rotates - тело вращается и сталкивается с телами повернутым боксом (ящики, транспорт).
+	// Персонажи не вращаются и остаются вертикальными AABB; коллайдер вращающегося тела описывает его бокс
+	Rotates         bool
+	Orientation     mgl32.Quat
+	AngularVelocity mgl32.Vec3
+	Torque          mgl32.Vec3
+	// Главные моменты инерции в осях тела (кг·м²) и скорость затухания вращения (1/с)
+	Inertia        mgl32.Vec3
+	AngularDamping float32
+
+	// Ускорение от гравитации и объемов сил, действовавшее на тело на последнем тике
+	FieldAcceleration mgl32.Vec3
+
+	// Sleeping - тело покоится и не обрабатывается движком до пробуждения
+	Sleeping bool
+	// Количество тиков подряд, в течение которых тело покоилось
+	sleepTimer int
 }
 
 // NewRigidBody создает новое физическое тело с заданной позицией, массой и размерами
@@ -55,159 +125,133 @@ func NewRigidBody(position mgl32.Vec3, mass, width, height float32) *RigidBody {
 
 		// Устанавливаем настраиваемые параметры по умолчанию
 		JumpSpeed:               DefaultJumpSpeed,
-		Gravity:                 DefaultGravity,
+		Gravity:                 mgl32.Vec3{0, -DefaultGravity, 0},
 		PenetrationEpsilonSmall: DefaultPenetrationEpsilonSmall,
 		PenetrationEpsilonBig:   DefaultPenetrationEpsilonBig,
 		AirMovementSuppression:  DefaultAirMovementSuppression,
 		FlyingSpeedMultipier:    DefaultFlyingSpeedMultipier,
 		PositionHistoryLength:   DefaultPositionHistoryLength,
+		AirDrag:                 DefaultAirDrag,
+		Buoyancy:                DefaultBuoyancy,
+		MaxClimbFall:            DefaultMaxClimbFall,
+		GroundMaterial:          DefaultMaterial,
+		Orientation:             mgl32.QuatIdent(),
+		AngularDamping:          DefaultAngularDamping,
 	}
 }
 
-// UpdateCollider обновляет коллайдер на основе текущей позиции
+// UpdateCollider обновляет коллайдер на основе текущей позиции.
+// Позиция тела соответствует центру нижней грани коллайдера (ногам).
 func (r *RigidBody) UpdateCollider() {
-	r.Collider = &Box{
-		Min: r.Position.Sub(mgl32.Vec3{r.Width / 2, r.Height, r.Width / 2}),
-		Max: r.Position.Add(mgl32.Vec3{r.Width / 2, 0, r.Width / 2}),
-	}
+	r.UpdateColliderAtPosition(r.Position)
 }
 
-// Move перемещает физическое тело с использованием прямой скорости.
-// Принимает опциональный пол и стены для вычисления коллизий.
-func (r *RigidBody) Move(movement mgl32.Vec3, ground *Box, ceiling *Box, walls []Box) {
-	wasGrounded := r.Grounded
-
-	// Проверяем состояние "на земле"
-	if ground != nil {
-		// Устанавливаем состояние "на земле" и сбрасываем вертикальную компоненту скорости
-		if !wasGrounded {
-			// Если только что приземлились, обнуляем вертикальную скорость
-			r.Velocity = mgl32.Vec3{r.Velocity.X(), 0.0, r.Velocity.Z()}
-		}
+// Move перемещает тело на заданное смещение с учетом коллизий с миром.
+// Смещение разрешается по осям (сначала Y, затем X и Z) методом swept AABB против всех форм,
+// попадающих в область движения, поэтому быстрые тела не проходят сквозь блоки, а при касании
+// стены тело скользит вдоль нее. Стоящее тело с ненулевым StepHeight забирается на уступы
+// не выше этой высоты, а тело с EdgeGuard не сходит с края опоры.
+// Компоненты скорости по заблокированным осям обнуляются.
+func (r *RigidBody) Move(movement mgl32.Vec3, world CollisionWorld) MoveResult {
+	r.UpdateCollider()
 
-		r.Grounded = true
+	if world == nil {
+		r.Position = r.Position.Add(movement)
+		r.UpdateCollider()
+		return MoveResult{Requested: movement, Actual: movement}
+	}
 
-		// Вычисляем коллизию с землей и перемещаем тело вверх
-		if r.Collider != nil {
-			b, depth := ground.IntersectionY(*r.Collider)
-			if b && depth > 0.001 {
-				// Увеличиваем запас при подъеме тела над землей - существенно больше, чтобы точно не провалиться
-				r.Position = r.Position.Add(mgl32.Vec3{0, depth + 0.2, 0})
-				// Обновляем коллайдер после перемещения
-				r.UpdateCollider()
-			}
+	box := *r.Collider
+	region := box.Stretch(movement)
+	if r.StepHeight > 0 {
+		region = region.Stretch(mgl32.Vec3{0, r.StepHeight, 0})
+	}
+	guard := r.EdgeGuard && r.Grounded && movement.Y() <= 0
+	if guard {
+		region = region.Stretch(mgl32.Vec3{0, -r.edgeGuardDepth(), 0})
+	}
+	obstacles := world.CollisionBoxes(region)
+
+	// Не даем стоящему телу сойти с края: по осям, где смещение урезано, гасим скорость
+	if guard {
+		guarded := guardEdges(obstacles, box, movement, r.edgeGuardDepth())
+		if guarded.X() != movement.X() {
+			r.Velocity[0] = 0
 		}
-	} else {
-		// Если нет земли под игроком, устанавливаем состояние "в воздухе"
-		r.Grounded = false
+		if guarded.Z() != movement.Z() {
+			r.Velocity[2] = 0
+		}
+		movement = guarded
 	}
 
-	// Если в воздухе, можем уменьшить движение
-	if !r.Grounded && !r.Flying {
-		movement = movement.Mul(r.AirMovementSuppression)
+	result := resolveMovement(obstacles, box, movement)
+
+	// Уперлись в стену, стоя на земле: пробуем подняться на уступ
+	onGround := result.Ground || (r.Grounded && movement.Y() <= 0)
+	if r.StepHeight > 0 && onGround && result.CollidedHorizontally() {
+		stepped := stepUp(obstacles, box, movement, r.StepHeight)
+		if horizontalLenSqr(stepped.Actual) > horizontalLenSqr(result.Actual) {
+			result = stepped
+		}
 	}
 
-	// Временный множитель при полете
-	if r.Flying {
-		movement = movement.Mul(r.FlyingSpeedMultipier)
+	r.Position = r.Position.Add(result.Actual)
+	r.UpdateCollider()
+
+	// Гасим скорость по осям, вдоль которых произошла коллизия
+	if result.Ground || result.Ceiling {
+		r.Velocity[1] = 0
+	}
+	if result.WallX {
+		r.Velocity[0] = 0
+	}
+	if result.WallZ {
+		r.Velocity[2] = 0
+	}
+	// Чисто горизонтальное смещение (например, расталкивание тел) не меняет опору
+	if movement.Y() != 0 {
+		r.Grounded = result.Ground
 	}
 
-	// Проверяем столкновения со стенами более точно и пошагово
-	if r.Collider != nil && len(walls) > 0 {
-		// Вертикальное движение выполняем маленькими шагами для надежности
-		// (особенно важно при падении)
-		verticalMove := mgl32.Vec3{0, 0, 0}
-		if !r.Flying && !r.Grounded {
-			verticalMove = mgl32.Vec3{0, r.Velocity.Y(), 0}
-		} else if r.Flying {
-			verticalMove = mgl32.Vec3{0, movement.Y(), 0}
-		}
+	return result
+}
 
-		// Если есть вертикальное движение, обрабатываем его маленькими шагами
-		if verticalMove.Len() > 0 {
-			// Количество шагов зависит от скорости падения
-			steps := int(mgl32.Abs(verticalMove.Y())*5.0) + 1 // Минимум 1 шаг
-			stepMove := verticalMove.Mul(1.0 / float32(steps))
-
-			for i := 0; i < steps; i++ {
-				// Проверяем следующую позицию
-				tempPos := r.Position.Add(stepMove)
-				r.UpdateColliderAtPosition(tempPos)
-
-				// Проверяем коллизии
-				hasCollision := false
-				for _, wall := range walls {
-					if b, _ := r.Collider.Intersection(wall); b {
-						hasCollision = true
-						break
-					}
-				}
-
-				// Если нет коллизии, применяем движение
-				if !hasCollision {
-					r.Position = tempPos
-				} else {
-					// Иначе останавливаемся и обнуляем скорость
-					if r.Velocity.Y() < 0 {
-						r.Grounded = true
-					}
-					r.Velocity = mgl32.Vec3{r.Velocity.X(), 0, r.Velocity.Z()}
-					break
-				}
-			}
+// Resize меняет высоту тела, сохраняя положение ног. Увеличение высоты не выполняется,
+// если новый коллайдер пересекся бы с формами мира; в этом случае возвращается false.
+func (r *RigidBody) Resize(height float32, world CollisionWorld) bool {
+	if height > r.Height && world != nil {
+		// Бокс уменьшен на допуск, чтобы касание опоры и стен не мешало выпрямиться
+		grown := Box{
+			Min: r.Position.Sub(mgl32.Vec3{r.Width/2 - CollisionEpsilon, -CollisionEpsilon, r.Width/2 - CollisionEpsilon}),
+			Max: r.Position.Add(mgl32.Vec3{r.Width/2 - CollisionEpsilon, height, r.Width/2 - CollisionEpsilon}),
 		}
-
-		// Горизонтальное движение тоже выполняем пошагово
-		horizMove := mgl32.Vec3{movement.X(), 0, movement.Z()}
-		if horizMove.Len() > 0.001 {
-			// Делим на 4 шага для плавности
-			steps := 4
-			stepMove := horizMove.Mul(1.0 / float32(steps))
-
-			for i := 0; i < steps; i++ {
-				// Проверяем движение по X и Z вместе
-				tempPos := r.Position.Add(stepMove)
-				r.UpdateColliderAtPosition(tempPos)
-
-				// Проверяем коллизии
-				hasCollision := false
-				for _, wall := range walls {
-					if b, _ := r.Collider.Intersection(wall); b {
-						hasCollision = true
-						break
-					}
-				}
-
-				// Если нет коллизии, применяем движение
-				if !hasCollision {
-					r.Position = tempPos
-				} else {
-					break // При коллизии дальше не двигаемся
-				}
+		for _, obstacle := range world.CollisionBoxes(grown) {
+			if grown.Overlaps(obstacle) {
+				return false
 			}
 		}
+	}
 
-		// Обновляем коллайдер для текущей позиции
-		r.UpdateCollider()
-	} else {
-		// Если нет стен или коллайдера, просто применяем движение
-		r.Position = r.Position.Add(movement)
-
-		// Применяем вертикальную составляющую скорости (если не в режиме полета)
-		if !r.Flying {
-			r.Position = r.Position.Add(mgl32.Vec3{0, r.Velocity.Y(), 0})
-		}
+	r.Height = height
+	r.UpdateCollider()
+	return true
+}
 
-		// Обновляем коллайдер
-		r.UpdateCollider()
-	}
+// edgeGuardDepth возвращает глубину, на которой под телом ищется опора для защиты от края
+func (r *RigidBody) edgeGuardDepth() float32 {
+	return maxf(r.StepHeight, groundProbeDepth)
 }
 
 // UpdateColliderAtPosition обновляет коллайдер для заданной позиции (для проверок)
 func (r *RigidBody) UpdateColliderAtPosition(position mgl32.Vec3) {
+	if r.Rotates {
+		bounds := r.orientedBoxAt(position).Bounds()
+		r.Collider = &bounds
+		return
+	}
 	r.Collider = &Box{
-		Min: position.Sub(mgl32.Vec3{r.Width / 2, r.Height, r.Width / 2}),
-		Max: position.Add(mgl32.Vec3{r.Width / 2, 0, r.Width / 2}),
+		Min: position.Sub(mgl32.Vec3{r.Width / 2, 0, r.Width / 2}),
+		Max: position.Add(mgl32.Vec3{r.Width / 2, r.Height, r.Width / 2}),
 	}
 }
 
@@ -219,6 +263,17 @@ func (r *RigidBody) Jump() {
 	}
 }
 
+// InterpolatedPosition возвращает положение между предыдущим тиком и текущим.
+// alpha = 0 соответствует предыдущему тику, alpha = 1 - текущей позиции.
+// Предыдущее положение берется из PositionHistory, куда движок записывает его на каждом тике.
+func (r *RigidBody) InterpolatedPosition(alpha float32) mgl32.Vec3 {
+	if len(r.PositionHistory) == 0 {
+		return r.Position
+	}
+	previous := r.PositionHistory[0]
+	return previous.Add(r.Position.Sub(previous).Mul(alpha))
+}
+
 // AppendHistory добавляет текущую позицию в историю
 func (r *RigidBody) AppendHistory() {
 	if r.PositionHistory == nil {
@@ -0,0 +1,331 @@
+package physics
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/geometry"
+)
+
+const (
+	// Скорость затухания угловой скорости (1/с)
+	DefaultAngularDamping = 0.5
+
+	// Коэффициент трения скольжения вращающегося тела по поверхности с трением DefaultFriction
+	DefaultContactFriction = 0.6
+
+	// Допуск, в пределах которого вершины повернутого бокса считаются касающимися грани контакта
+	rotationContactSlop = 0.005
+
+	// Количество итераций выталкивания из блоков бокса, задевшего их при повороте,
+	// и итераций импульсов в вершинах контакта
+	rotationDepenetrationIterations = 4
+	rotationContactIterations       = 8
+
+	// Минимальная вертикальная составляющая нормали, при которой тело стоит на другом теле
+	groundNormalY = 0.7
+)
+
+// NewRotatingBody создает тело, которое вращается и сталкивается с другими телами повернутым боксом
+// (ящики, транспорт). Моменты инерции вычисляются как для сплошного бокса.
+func NewRotatingBody(position mgl32.Vec3, mass, width, height float32) *RigidBody {
+	body := NewRigidBody(position, mass, width, height)
+	body.Rotates = true
+	body.Inertia = BoxInertia(mass, mgl32.Vec3{width, height, width})
+	body.UpdateCollider()
+	return body
+}
+
+// BoxInertia возвращает главные моменты инерции сплошного бокса заданной массы и размеров
+func BoxInertia(mass float32, size mgl32.Vec3) mgl32.Vec3 {
+	x2, y2, z2 := size.X()*size.X(), size.Y()*size.Y(), size.Z()*size.Z()
+	return mgl32.Vec3{
+		mass * (y2 + z2) / 12,
+		mass * (x2 + z2) / 12,
+		mass * (x2 + y2) / 12,
+	}
+}
+
+// Center возвращает центр коллайдера тела, вокруг которого тело вращается
+func (r *RigidBody) Center() mgl32.Vec3 {
+	return r.Position.Add(mgl32.Vec3{0, r.Height / 2, 0})
+}
+
+// OrientedBox возвращает повернутый бокс тела. У невращающегося тела он совпадает с коллайдером.
+func (r *RigidBody) OrientedBox() geometry.OrientedBox {
+	return r.orientedBoxAt(r.Position)
+}
+
+// orientedBoxAt возвращает повернутый бокс тела в заданной позиции
+func (r *RigidBody) orientedBoxAt(position mgl32.Vec3) geometry.OrientedBox {
+	orientation := mgl32.QuatIdent()
+	if r.Rotates {
+		orientation = r.Orientation
+	}
+	return geometry.NewOrientedBox(
+		position.Add(mgl32.Vec3{0, r.Height / 2, 0}),
+		mgl32.Vec3{r.Width / 2, r.Height / 2, r.Width / 2},
+		orientation,
+	)
+}
+
+// ApplyForceAt прикладывает силу в точке. Сила, приложенная не к центру, закручивает вращающееся тело.
+func (r *RigidBody) ApplyForceAt(force, point mgl32.Vec3) {
+	r.Force = r.Force.Add(force)
+	if r.Rotates {
+		r.Torque = r.Torque.Add(point.Sub(r.Center()).Cross(force))
+	}
+}
+
+// ApplyImpulseAt мгновенно меняет скорость тела импульсом (Н·с), приложенным в точке
+func (r *RigidBody) ApplyImpulseAt(impulse, point mgl32.Vec3) {
+	inverseMass := r.inverseMass()
+	if inverseMass == 0 {
+		return
+	}
+	r.Velocity = r.Velocity.Add(impulse.Mul(inverseMass))
+	if r.Rotates {
+		r.AngularVelocity = r.AngularVelocity.Add(r.inverseInertia().Mul3x1(point.Sub(r.Center()).Cross(impulse)))
+	}
+}
+
+// inverseMass возвращает обратную массу тела (0 для неподвижных и кинематических тел и для мира)
+func (r *RigidBody) inverseMass() float32 {
+	if r == nil || r.Kinematic || r.Mass <= 0 {
+		return 0
+	}
+	return 1 / r.Mass
+}
+
+// inverseInertia возвращает обратный тензор инерции в мировых координатах (нулевой у невращающихся тел)
+func (r *RigidBody) inverseInertia() mgl32.Mat3 {
+	if r == nil || !r.Rotates || r.Kinematic {
+		return mgl32.Mat3{}
+	}
+	var inverse mgl32.Mat3
+	for i := 0; i < 3; i++ {
+		if r.Inertia[i] > 0 {
+			inverse.Set(i, i, 1/r.Inertia[i])
+		}
+	}
+	rotation := r.Orientation.Normalize().Mat4().Mat3()
+	return rotation.Mul3(inverse).Mul3(rotation.Transpose())
+}
+
+// pointVelocity возвращает скорость точки тела с учетом вращения
+func (r *RigidBody) pointVelocity(point mgl32.Vec3) mgl32.Vec3 {
+	if r == nil {
+		return mgl32.Vec3{}
+	}
+	if !r.Rotates {
+		return r.Velocity
+	}
+	return r.Velocity.Add(r.AngularVelocity.Cross(point.Sub(r.Center())))
+}
+
+// rotate интегрирует вращение тела под действием момента сил и выталкивает из блоков
+// описанный бокс, задевший их при повороте
+func (p *PhysicsEngine) rotate(body *RigidBody, delta float64) {
+	dt := float32(delta)
+	acceleration := body.inverseInertia().Mul3x1(body.Torque)
+	body.AngularVelocity = body.AngularVelocity.Add(acceleration.Mul(dt)).
+		Mul(float32(math.Exp(-float64(body.AngularDamping) * delta)))
+	if body.AngularVelocity == (mgl32.Vec3{}) {
+		return
+	}
+
+	// dq/dt = ω q / 2
+	spin := mgl32.Quat{V: body.AngularVelocity}
+	body.Orientation = body.Orientation.Add(spin.Mul(body.Orientation).Scale(dt / 2)).Normalize()
+	body.UpdateCollider()
+	p.depenetrate(body)
+}
+
+// depenetrate выталкивает коллайдер тела из форм мира вдоль оси наименьшего проникновения
+func (p *PhysicsEngine) depenetrate(body *RigidBody) {
+	if p.world == nil {
+		return
+	}
+	for i := 0; i < rotationDepenetrationIterations; i++ {
+		collider := *body.Collider
+		deepest := float32(0)
+		push := mgl32.Vec3{}
+		for _, box := range p.world.CollisionBoxes(collider) {
+			axis, depth, direction := penetrationAxis(box, collider)
+			if depth > deepest {
+				deepest = depth
+				push = mgl32.Vec3{}
+				push[axis] = direction * depth
+			}
+		}
+		if deepest <= CollisionEpsilon {
+			return
+		}
+		body.Position = body.Position.Add(push)
+		body.UpdateCollider()
+	}
+}
+
+// contactPoint - точка контакта вращающегося тела с формой мира или другим телом и накопленные в ней импульсы
+type contactPoint struct {
+	point  mgl32.Vec3
+	normal mgl32.Vec3
+
+	// Скорость отскока вдоль нормали и коэффициент трения
+	target   float32
+	friction float32
+
+	normalImpulse  float32
+	tangentImpulse mgl32.Vec3
+}
+
+// resolveRotatingContacts заменяет гашение скорости по осям контакта, выполненное Move,
+// импульсами в вершинах повернутого бокса, касающихся форм мира. Импульс в вершине закручивает тело,
+// поэтому наклоненный ящик опрокидывается на грань, а скользящий по полу - кувыркается.
+// velocity - скорость тела до перемещения. Возвращает true, если тело отскочило от опоры.
+func (p *PhysicsEngine) resolveRotatingContacts(body *RigidBody, result MoveResult, velocity mgl32.Vec3) bool {
+	body.Velocity = velocity
+	corners := body.OrientedBox().Corners()
+
+	bounced := false
+	contacts := make([]contactPoint, 0, 8)
+	for _, contact := range result.Contacts {
+		points := contactCorners(corners, contact.Normal)
+		material := DefaultMaterial
+		if p.world != nil {
+			material = p.world.MaterialAt(points[0].Sub(contact.Normal.Mul(groundProbeDepth)))
+		}
+
+		target := float32(0)
+		if approach := -velocity.Dot(contact.Normal); approach > MinBounceSpeed && material.Bounciness > 0 {
+			target = approach * material.Bounciness
+			bounced = bounced || contact.Normal.Y() > 0
+		}
+		for _, point := range points {
+			contacts = append(contacts, contactPoint{
+				point:    point,
+				normal:   contact.Normal,
+				target:   target,
+				friction: contactFriction(material),
+			})
+		}
+	}
+
+	solveContacts(nil, body, contacts)
+	return bounced
+}
+
+// contactCorners возвращает вершины бокса, ближайшие к грани контакта с нормалью normal
+func contactCorners(corners [8]mgl32.Vec3, normal mgl32.Vec3) []mgl32.Vec3 {
+	lowest := corners[0].Dot(normal)
+	for _, corner := range corners[1:] {
+		lowest = minf(lowest, corner.Dot(normal))
+	}
+
+	points := make([]mgl32.Vec3, 0, 4)
+	for _, corner := range corners {
+		if corner.Dot(normal) <= lowest+rotationContactSlop {
+			points = append(points, corner)
+		}
+	}
+	return points
+}
+
+// contactFriction возвращает коэффициент трения скольжения по материалу
+func contactFriction(material Material) float32 {
+	return DefaultContactFriction * material.Friction / DefaultFriction
+}
+
+// solveContacts гасит сближение тел в точках контакта последовательными импульсами вдоль нормалей,
+// направленных от a к b, и трением по касательной. a равно nil для контакта с миром.
+// Накопленный импульс в каждой точке остается отталкивающим, а трение не превышает friction от него,
+// поэтому лежащий на грани ящик не раскачивается.
+func solveContacts(a, b *RigidBody, contacts []contactPoint) {
+	for i := 0; i < rotationContactIterations; i++ {
+		for j := range contacts {
+			c := &contacts[j]
+			k := inverseMassAlong(a, c.point, c.normal) + inverseMassAlong(b, c.point, c.normal)
+			if k <= 0 {
+				continue
+			}
+			speed := relativeVelocity(a, b, c.point).Dot(c.normal)
+			impulse := maxf(c.normalImpulse+(c.target-speed)/k, 0)
+			applyImpulsePair(a, b, c.normal.Mul(impulse-c.normalImpulse), c.point)
+			c.normalImpulse = impulse
+
+			relative := relativeVelocity(a, b, c.point)
+			tangent := relative.Sub(c.normal.Mul(relative.Dot(c.normal)))
+			if slip := tangent.Len(); slip > CollisionEpsilon {
+				tangent = tangent.Mul(1 / slip)
+				k = inverseMassAlong(a, c.point, tangent) + inverseMassAlong(b, c.point, tangent)
+				accumulated := c.tangentImpulse.Add(tangent.Mul(-slip / k))
+				if limit := c.friction * c.normalImpulse; accumulated.Len() > limit {
+					accumulated = accumulated.Normalize().Mul(limit)
+				}
+				applyImpulsePair(a, b, accumulated.Sub(c.tangentImpulse), c.point)
+				c.tangentImpulse = accumulated
+			}
+		}
+	}
+}
+
+// relativeVelocity возвращает скорость точки тела b относительно тела a
+func relativeVelocity(a, b *RigidBody, point mgl32.Vec3) mgl32.Vec3 {
+	return b.pointVelocity(point).Sub(a.pointVelocity(point))
+}
+
+// applyImpulsePair прикладывает импульс к телу b и противоположный ему к телу a
+func applyImpulsePair(a, b *RigidBody, impulse, point mgl32.Vec3) {
+	b.ApplyImpulseAt(impulse, point)
+	if a != nil {
+		a.ApplyImpulseAt(impulse.Mul(-1), point)
+	}
+}
+
+// inverseMassAlong возвращает обратную эффективную массу тела для импульса вдоль направления в точке
+func inverseMassAlong(body *RigidBody, point, direction mgl32.Vec3) float32 {
+	inverseMass := body.inverseMass()
+	if inverseMass == 0 || !body.Rotates {
+		return inverseMass
+	}
+	arm := point.Sub(body.Center())
+	return inverseMass + direction.Dot(body.inverseInertia().Mul3x1(arm.Cross(direction)).Cross(arm))
+}
+
+// separateOriented разводит пару, в которой хотя бы одно тело вращается, по повернутым боксам
+// и гасит сближение импульсами в точках контакта, закручивающими вращающиеся тела
+func (p *PhysicsEngine) separateOriented(a, b *RigidBody) {
+	boxA, boxB := a.OrientedBox(), b.OrientedBox()
+	normal, depth, ok := boxA.Penetration(boxB)
+	if !ok || depth <= CollisionEpsilon {
+		return
+	}
+	points := boxA.ContactPoints(boxB, normal)
+
+	// Нормаль направлена от нижнего тела к верхнему
+	lower, upper, up := a, b, normal
+	if normal.Y() < 0 {
+		lower, upper, up = b, a, normal.Mul(-1)
+	}
+	standing := up.Y() > groundNormalY
+
+	contacts := make([]contactPoint, len(points))
+	for i, point := range points {
+		contacts[i] = contactPoint{point: point, normal: up, friction: DefaultContactFriction}
+	}
+
+	// Стоящее на опоре нижнее тело служит неподвижной опорой верхнему: его собственные контакты
+	// ответят на давление только на следующем тике, и импульс вне центра опрокинул бы стопку
+	if standing && lower.Grounded {
+		upper.Move(up.Mul(depth), p.world)
+		solveContacts(nil, upper, contacts)
+	} else {
+		p.pushApart(a, b, normal, depth)
+		solveContacts(lower, upper, contacts)
+	}
+
+	// Тело, стоящее на другом теле, считается стоящим на земле
+	if standing {
+		upper.Grounded = true
+	}
+}
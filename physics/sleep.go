@@ -0,0 +1,110 @@
+package physics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// Количество тиков покоя, после которого тело засыпает
+	DefaultSleepTicks = 60
+
+	// Скорость (м/с), ниже которой смещение тела за тик считается покоем
+	DefaultSleepVelocity = 0.05
+
+	// Зазор, в пределах которого пробуждение передается соседним телам
+	sleepWakeMargin = 0.05
+)
+
+// Wake пробуждает спящее тело и спящие тела, касающиеся его
+func (p *PhysicsEngine) Wake(body *RigidBody) {
+	body.sleepTimer = 0
+	if !body.Sleeping {
+		return
+	}
+	body.Sleeping = false
+	if body.Collider != nil {
+		p.WakeRegion(*body.Collider)
+	}
+}
+
+// WakeRegion пробуждает спящие тела, коллайдеры которых пересекают область или касаются ее.
+// Вызывается при изменении блоков, чтобы тела не повисли в воздухе без опоры.
+func (p *PhysicsEngine) WakeRegion(region Box) {
+	for _, body := range p.QueryRegion(region.Grow(sleepWakeMargin)) {
+		if body.Sleeping {
+			p.Wake(body)
+		}
+	}
+}
+
+// wakeDisturbed будит спящие тела, состояние которых изменили между тиками:
+// приложили силу, придали скорость (взрыв, попадание снаряда, прыжок) или лишили опоры
+func (p *PhysicsEngine) wakeDisturbed(bodies []*RigidBody) {
+	for _, body := range bodies {
+		if !body.Sleeping {
+			continue
+		}
+		if body.Velocity != (mgl32.Vec3{}) || body.Force != (mgl32.Vec3{}) || body.Movement != (mgl32.Vec3{}) ||
+			body.AngularVelocity != (mgl32.Vec3{}) || body.Torque != (mgl32.Vec3{}) || !body.Grounded || body.Flying {
+			p.Wake(body)
+		}
+	}
+}
+
+// wakeByContact будит спящее тело пары, в которое вошло бодрствующее тело.
+// Неподвижная платформа и касание без проникновения спящее тело не будят.
+func (p *PhysicsEngine) wakeByContact(pair BodyPair) {
+	sleeping, other := pair.A, pair.B
+	if other.Sleeping {
+		sleeping, other = other, sleeping
+	}
+	if !sleeping.Sleeping || other.Sleeping {
+		return
+	}
+	if other.Kinematic && other.Velocity == (mgl32.Vec3{}) {
+		return
+	}
+	if _, depth, _ := penetrationAxis(*pair.A.Collider, *pair.B.Collider); depth > CollisionEpsilon {
+		p.Wake(sleeping)
+	}
+}
+
+// updateSleep усыпляет острова тел, все тела которых покоились SleepTicks тиков подряд.
+// Покоящимся считается стоящее тело, сместившееся за тик меньше, чем на SleepVelocity * delta,
+// не вращающееся быстрее SleepVelocity рад/с, к которому не прикладывали сил. Тела в жидкости, на лестницах и на платформах не засыпают.
+// Острова засыпают целиком, чтобы стоящие друг на друге тела не будили друг друга.
+func (p *PhysicsEngine) updateSleep(bodies []*RigidBody, delta float64) {
+	if p.SleepTicks <= 0 {
+		return
+	}
+	limit := p.SleepVelocity * float32(delta)
+
+	groups, _ := islands(bodies, pairIndices(p.pairs, indexOf(bodies)))
+	for _, group := range groups {
+		ready := true
+		for _, i := range group {
+			body := bodies[i]
+			if body.Sleeping {
+				continue
+			}
+			resting := body.Grounded && !body.Flying && !body.InFluid && !body.Climbing && body.Platform == nil &&
+				len(body.PositionHistory) > 0 && body.Position.Sub(body.PositionHistory[0]).Len() <= limit &&
+				body.AngularVelocity.Len() <= p.SleepVelocity
+			if resting {
+				body.sleepTimer++
+			} else {
+				body.sleepTimer = 0
+			}
+			ready = ready && body.sleepTimer >= p.SleepTicks
+		}
+		if !ready {
+			continue
+		}
+
+		for _, i := range group {
+			bodies[i].Sleeping = true
+			bodies[i].Velocity = mgl32.Vec3{}
+			bodies[i].AngularVelocity = mgl32.Vec3{}
+		}
+	}
+}
@@ -0,0 +1,528 @@
+package physics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// Сигнатура и версия формата снимка состояния
+	snapshotMagic   = "GEPS"
+	snapshotVersion = 5
+)
+
+// Флаги состояния тела в снимке
+const (
+	snapshotFlying uint8 = 1 << iota
+	snapshotGrounded
+	snapshotSelfPropelled
+	snapshotEdgeGuard
+	snapshotInFluid
+	snapshotClimbing
+	snapshotSleeping
+)
+
+// Snapshot - компактный двоичный снимок динамического состояния движка
+type Snapshot []byte
+
+// Snapshot сохраняет динамическое состояние всех зарегистрированных тел, контроллеров движения,
+// контактов, датчиков и снарядов. Параметры тел (масса, размеры, гравитация) и мир в снимок не входят:
+// восстанавливать снимок нужно в движок с теми же телами, зарегистрированными в том же порядке.
+// Снаряды, набор которых меняется от тика к тику, сохраняются вместе с параметрами.
+func (p *PhysicsEngine) Snapshot() Snapshot {
+	w := &snapshotWriter{}
+	w.buf.WriteString(snapshotMagic)
+	w.u8(snapshotVersion)
+	w.u64(p.tick)
+
+	index := p.bodyIndex()
+
+	// Тела
+	w.u32(uint32(len(p.bodies)))
+	for _, body := range p.bodies {
+		w.body(body)
+		// Платформа записывается номером тела, увеличенным на 1 (0 - нет платформы)
+		platform, ok := index[body.Platform]
+		if ok {
+			platform++
+		}
+		w.u32(uint32(platform))
+	}
+
+	// Контроллеры движения
+	w.u32(uint32(len(p.controllers)))
+	for _, c := range p.controllers {
+		w.controller(c)
+	}
+
+	// Пары тел, соприкасавшиеся на последнем тике
+	w.u32(uint32(len(p.pairs)))
+	for _, pair := range p.pairs {
+		w.u32(uint32(index[pair.A]))
+		w.u32(uint32(index[pair.B]))
+	}
+
+	// Тела внутри датчиков
+	w.u32(uint32(len(p.sensors)))
+	for _, sensor := range p.sensors {
+		occupants := make([]*RigidBody, 0, len(sensor.occupants))
+		for _, body := range sensor.occupants {
+			if _, ok := index[body]; ok {
+				occupants = append(occupants, body)
+			}
+		}
+		w.u32(uint32(len(occupants)))
+		for _, body := range occupants {
+			w.u32(uint32(index[body]))
+		}
+	}
+
+	// Снаряды
+	w.u64(p.projectileSerial)
+	w.u32(uint32(len(p.projectiles)))
+	for _, pr := range p.projectiles {
+		w.projectile(pr, index)
+	}
+
+	return Snapshot(w.buf.Bytes())
+}
+
+// Restore восстанавливает состояние, сохраненное методом Snapshot.
+// Снаряд, все еще зарегистрированный в движке, сохраняет свой адрес и обработчик OnHit;
+// снаряды, остановившиеся после снимка, создаются заново без обработчика, а выпущенные после него удаляются.
+// Возвращает ошибку, если снимок поврежден или не соответствует зарегистрированным телам.
+func (p *PhysicsEngine) Restore(snapshot Snapshot) error {
+	r := &snapshotReader{r: bytes.NewReader(snapshot)}
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r.r, magic); err != nil || string(magic) != snapshotMagic {
+		return fmt.Errorf("Неверная сигнатура снимка физики")
+	}
+	if version := r.u8(); version != snapshotVersion {
+		return fmt.Errorf("Неподдерживаемая версия снимка физики: %d", version)
+	}
+	tick := r.u64()
+
+	if count := int(r.u32()); r.err == nil && count != len(p.bodies) {
+		return fmt.Errorf("Снимок содержит %d тел, зарегистрировано %d", count, len(p.bodies))
+	}
+	states := make([]RigidBody, len(p.bodies))
+	for i := range states {
+		r.body(&states[i])
+		if platform := r.u32(); platform > 0 {
+			if int(platform) > len(p.bodies) {
+				return fmt.Errorf("Номер платформы вне диапазона: %d", platform)
+			}
+			states[i].Platform = p.bodies[platform-1]
+		}
+	}
+
+	if count := int(r.u32()); r.err == nil && count != len(p.controllers) {
+		return fmt.Errorf("Снимок содержит %d контроллеров, зарегистрировано %d", count, len(p.controllers))
+	}
+	controllers := make([]MovementController, len(p.controllers))
+	for i := range controllers {
+		r.controller(&controllers[i])
+	}
+
+	pairs := make([]BodyPair, r.count(8))
+	for i := range pairs {
+		a, b := r.bodyRef(p.bodies), r.bodyRef(p.bodies)
+		pairs[i] = BodyPair{A: a, B: b}
+	}
+
+	if count := int(r.u32()); r.err == nil && count != len(p.sensors) {
+		return fmt.Errorf("Снимок содержит %d датчиков, зарегистрировано %d", count, len(p.sensors))
+	}
+	occupants := make([][]*RigidBody, len(p.sensors))
+	for i := range occupants {
+		occupants[i] = make([]*RigidBody, r.count(4))
+		for j := range occupants[i] {
+			occupants[i][j] = r.bodyRef(p.bodies)
+		}
+	}
+
+	serial := r.u64()
+	projectiles := make([]*Projectile, r.count(4))
+	for i := range projectiles {
+		projectiles[i] = r.projectile(p.bodies)
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("Ошибка чтения снимка физики: %v", r.err)
+	}
+
+	// Снимок прочитан целиком - применяем состояние
+	p.tick = tick
+	for i, body := range p.bodies {
+		body.restore(&states[i])
+	}
+	for i, c := range p.controllers {
+		c.restore(&controllers[i])
+	}
+	p.pairs = pairs
+	p.touching = make(map[BodyPair]bool, len(pairs))
+	for _, pair := range pairs {
+		p.touching[pair] = true
+	}
+	for i, sensor := range p.sensors {
+		sensor.occupants = occupants[i]
+	}
+	p.events = nil
+	p.projectileSerial = serial
+	p.adoptProjectiles(projectiles)
+
+	// Пространственный хеш перестраивается по восстановленным позициям
+	p.broadphase.Clear()
+	for _, body := range p.bodies {
+		p.broadphase.Insert(body)
+	}
+
+	return nil
+}
+
+// bodyIndex возвращает порядковые номера зарегистрированных тел
+func (p *PhysicsEngine) bodyIndex() map[*RigidBody]int {
+	return indexOf(p.bodies)
+}
+
+// adoptProjectiles заменяет снаряды движка прочитанными. Снаряд, зарегистрированный под тем же номером,
+// получает прочитанное состояние, сохраняя адрес и обработчик OnHit.
+func (p *PhysicsEngine) adoptProjectiles(projectiles []*Projectile) {
+	registered := make(map[uint64]*Projectile, len(p.projectiles))
+	for _, pr := range p.projectiles {
+		registered[pr.id] = pr
+	}
+	for i, pr := range projectiles {
+		if existing, ok := registered[pr.id]; ok {
+			pr.OnHit = existing.OnHit
+			*existing = *pr
+			projectiles[i] = existing
+		}
+	}
+	p.projectiles = projectiles
+}
+
+// restore копирует динамическое состояние тела из прочитанного снимка
+func (r *RigidBody) restore(state *RigidBody) {
+	r.Position = state.Position
+	r.Velocity = state.Velocity
+	r.Force = state.Force
+	r.Movement = state.Movement
+	r.Orientation = state.Orientation
+	r.AngularVelocity = state.AngularVelocity
+	r.Torque = state.Torque
+	r.FieldAcceleration = state.FieldAcceleration
+	r.Height = state.Height
+	r.TripDistance = state.TripDistance
+	r.FallDistance = state.FallDistance
+	r.Submersion = state.Submersion
+	r.GroundMaterial = state.GroundMaterial
+	r.contactFaces = state.contactFaces
+	r.Flying = state.Flying
+	r.Grounded = state.Grounded
+	r.SelfPropelled = state.SelfPropelled
+	r.EdgeGuard = state.EdgeGuard
+	r.InFluid = state.InFluid
+	r.Climbing = state.Climbing
+	r.Sleeping = state.Sleeping
+	r.sleepTimer = state.sleepTimer
+	r.PositionHistory = state.PositionHistory
+	r.pathTime = state.pathTime
+	r.Platform = state.Platform
+	r.UpdateCollider()
+}
+
+// restore копирует состояние контроллера из прочитанного снимка
+func (m *MovementController) restore(state *MovementController) {
+	m.Input = state.Input
+	m.Flying = state.Flying
+	m.Sprinting = state.Sprinting
+	m.Crouching = state.Crouching
+	m.Swimming = state.Swimming
+	m.coyoteTimer = state.coyoteTimer
+	m.jumpBufferTimer = state.jumpBufferTimer
+}
+
+// snapshotWriter записывает значения снимка в little-endian
+type snapshotWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *snapshotWriter) u8(v uint8) {
+	w.buf.WriteByte(v)
+}
+
+func (w *snapshotWriter) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.buf.Write(b[:])
+}
+
+func (w *snapshotWriter) u64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	w.buf.Write(b[:])
+}
+
+func (w *snapshotWriter) f32(v float32) {
+	w.u32(math.Float32bits(v))
+}
+
+func (w *snapshotWriter) vec(v mgl32.Vec3) {
+	w.f32(v.X())
+	w.f32(v.Y())
+	w.f32(v.Z())
+}
+
+func (w *snapshotWriter) quat(q mgl32.Quat) {
+	w.f32(q.W)
+	w.vec(q.V)
+}
+
+func (w *snapshotWriter) bool(v bool) {
+	if v {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+// body записывает динамическое состояние тела
+func (w *snapshotWriter) body(body *RigidBody) {
+	flags := uint8(0)
+	setFlag := func(flag uint8, set bool) {
+		if set {
+			flags |= flag
+		}
+	}
+	setFlag(snapshotFlying, body.Flying)
+	setFlag(snapshotGrounded, body.Grounded)
+	setFlag(snapshotSelfPropelled, body.SelfPropelled)
+	setFlag(snapshotEdgeGuard, body.EdgeGuard)
+	setFlag(snapshotInFluid, body.InFluid)
+	setFlag(snapshotClimbing, body.Climbing)
+	setFlag(snapshotSleeping, body.Sleeping)
+	w.u8(flags)
+	w.u8(body.contactFaces)
+
+	w.vec(body.Position)
+	w.vec(body.Velocity)
+	w.vec(body.Force)
+	w.vec(body.Movement)
+	w.quat(body.Orientation)
+	w.vec(body.AngularVelocity)
+	w.vec(body.Torque)
+	w.vec(body.FieldAcceleration)
+	w.f32(body.Height)
+	w.f32(body.TripDistance)
+	w.f32(body.FallDistance)
+	w.f32(body.Submersion)
+	w.f32(body.GroundMaterial.Friction)
+	w.f32(body.GroundMaterial.Bounciness)
+	w.f32(body.GroundMaterial.SpeedFactor)
+	w.u64(math.Float64bits(body.pathTime))
+	w.u32(uint32(body.sleepTimer))
+
+	w.u32(uint32(len(body.PositionHistory)))
+	for _, pos := range body.PositionHistory {
+		w.vec(pos)
+	}
+}
+
+// projectile записывает состояние и параметры снаряда. Владелец записывается номером тела,
+// увеличенным на 1 (0 - нет владельца или он не зарегистрирован).
+func (w *snapshotWriter) projectile(pr *Projectile, index map[*RigidBody]int) {
+	w.u64(pr.id)
+	w.vec(pr.Position)
+	w.vec(pr.Velocity)
+	w.f32(pr.Radius)
+	w.f32(pr.Mass)
+	w.vec(pr.Gravity)
+	w.f32(pr.Drag)
+	w.f32(pr.Bounciness)
+	w.u32(uint32(int32(pr.Bounces)))
+	w.bool(pr.Active)
+
+	owner, ok := index[pr.Owner]
+	if ok {
+		owner++
+	}
+	w.u32(uint32(owner))
+}
+
+// controller записывает ввод и состояние контроллера движения
+func (w *snapshotWriter) controller(c *MovementController) {
+	w.f32(c.Input.Forward)
+	w.f32(c.Input.Right)
+	w.f32(c.Input.Up)
+	w.bool(c.Input.Jump)
+	w.bool(c.Input.Sprint)
+	w.bool(c.Input.Crouch)
+	w.vec(c.Input.ViewVector)
+	w.vec(c.Input.RightVector)
+
+	w.bool(c.Flying)
+	w.bool(c.Sprinting)
+	w.bool(c.Crouching)
+	w.bool(c.Swimming)
+	w.f32(c.coyoteTimer)
+	w.f32(c.jumpBufferTimer)
+}
+
+// snapshotReader читает значения снимка, запоминая первую ошибку
+type snapshotReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *snapshotReader) read(b []byte) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = io.ReadFull(r.r, b)
+}
+
+func (r *snapshotReader) u8() uint8 {
+	var b [1]byte
+	r.read(b[:])
+	return b[0]
+}
+
+func (r *snapshotReader) u32() uint32 {
+	var b [4]byte
+	r.read(b[:])
+	return binary.LittleEndian.Uint32(b[:])
+}
+
+func (r *snapshotReader) u64() uint64 {
+	var b [8]byte
+	r.read(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (r *snapshotReader) f32() float32 {
+	return math.Float32frombits(r.u32())
+}
+
+func (r *snapshotReader) vec() mgl32.Vec3 {
+	return mgl32.Vec3{r.f32(), r.f32(), r.f32()}
+}
+
+func (r *snapshotReader) quat() mgl32.Quat {
+	return mgl32.Quat{W: r.f32(), V: r.vec()}
+}
+
+func (r *snapshotReader) bool() bool {
+	return r.u8() != 0
+}
+
+// count читает количество элементов размером size байт и проверяет, что они помещаются в остаток снимка
+func (r *snapshotReader) count(size int) int {
+	n := int(r.u32())
+	if r.err != nil {
+		return 0
+	}
+	if n > r.r.Len()/size {
+		r.err = fmt.Errorf("количество элементов превышает размер снимка: %d", n)
+		return 0
+	}
+	return n
+}
+
+// bodyRef читает порядковый номер тела и возвращает само тело
+func (r *snapshotReader) bodyRef(bodies []*RigidBody) *RigidBody {
+	i := int(r.u32())
+	if r.err != nil {
+		return nil
+	}
+	if i >= len(bodies) {
+		r.err = fmt.Errorf("номер тела вне диапазона: %d", i)
+		return nil
+	}
+	return bodies[i]
+}
+
+// body читает состояние тела, записанное snapshotWriter.body
+func (r *snapshotReader) body(body *RigidBody) {
+	flags := r.u8()
+	body.Flying = flags&snapshotFlying != 0
+	body.Grounded = flags&snapshotGrounded != 0
+	body.SelfPropelled = flags&snapshotSelfPropelled != 0
+	body.EdgeGuard = flags&snapshotEdgeGuard != 0
+	body.InFluid = flags&snapshotInFluid != 0
+	body.Climbing = flags&snapshotClimbing != 0
+	body.Sleeping = flags&snapshotSleeping != 0
+	body.contactFaces = r.u8()
+
+	body.Position = r.vec()
+	body.Velocity = r.vec()
+	body.Force = r.vec()
+	body.Movement = r.vec()
+	body.Orientation = r.quat()
+	body.AngularVelocity = r.vec()
+	body.Torque = r.vec()
+	body.FieldAcceleration = r.vec()
+	body.Height = r.f32()
+	body.TripDistance = r.f32()
+	body.FallDistance = r.f32()
+	body.Submersion = r.f32()
+	body.GroundMaterial.Friction = r.f32()
+	body.GroundMaterial.Bounciness = r.f32()
+	body.GroundMaterial.SpeedFactor = r.f32()
+	body.pathTime = math.Float64frombits(r.u64())
+	body.sleepTimer = int(r.u32())
+
+	body.PositionHistory = make([]mgl32.Vec3, r.count(12))
+	for i := range body.PositionHistory {
+		body.PositionHistory[i] = r.vec()
+	}
+}
+
+// projectile читает снаряд, записанный snapshotWriter.projectile
+func (r *snapshotReader) projectile(bodies []*RigidBody) *Projectile {
+	pr := &Projectile{
+		id:         r.u64(),
+		Position:   r.vec(),
+		Velocity:   r.vec(),
+		Radius:     r.f32(),
+		Mass:       r.f32(),
+		Gravity:    r.vec(),
+		Drag:       r.f32(),
+		Bounciness: r.f32(),
+		Bounces:    int(int32(r.u32())),
+		Active:     r.bool(),
+	}
+	if owner := r.u32(); owner > 0 && r.err == nil {
+		if int(owner) > len(bodies) {
+			r.err = fmt.Errorf("номер владельца снаряда вне диапазона: %d", owner)
+			return pr
+		}
+		pr.Owner = bodies[owner-1]
+	}
+	return pr
+}
+
+// controller читает состояние контроллера, записанное snapshotWriter.controller
+func (r *snapshotReader) controller(c *MovementController) {
+	c.Input.Forward = r.f32()
+	c.Input.Right = r.f32()
+	c.Input.Up = r.f32()
+	c.Input.Jump = r.bool()
+	c.Input.Sprint = r.bool()
+	c.Input.Crouch = r.bool()
+	c.Input.ViewVector = r.vec()
+	c.Input.RightVector = r.vec()
+
+	c.Flying = r.bool()
+	c.Sprinting = r.bool()
+	c.Crouching = r.bool()
+	c.Swimming = r.bool()
+	c.coyoteTimer = r.f32()
+	c.jumpBufferTimer = r.f32()
+}
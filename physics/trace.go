@@ -0,0 +1,878 @@
+package physics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// Сигнатура и версия формата журнала физики
+	traceMagic   = "GEPT"
+	traceVersion = 6
+)
+
+// Обработчики, изменения которых записываются в журнал во время тика
+const (
+	tracePositionHandlers = iota
+	traceEventHandlers
+)
+
+// Флаги параметров тела в заголовке журнала
+const (
+	traceKinematic uint8 = 1 << iota
+	traceHasPath
+	traceRotates
+)
+
+// Trace - журнал симуляции, записанный методом StartTrace
+type Trace struct {
+	// Параметры тел, контроллеров движения, датчиков и объемов сил на момент начала записи
+	Bodies       []*RigidBody
+	Controllers  []*MovementController
+	Sensors      []*Sensor
+	ForceVolumes []*ForceVolume
+
+	// Параметры засыпания тел
+	SleepTicks    int
+	SleepVelocity float32
+
+	// Состояние движка на момент начала записи
+	Initial Snapshot
+
+	// Записи тиков в порядке выполнения
+	Ticks []TraceTick
+}
+
+// TraceTick - запись одного тика
+type TraceTick struct {
+	Tick  uint64
+	Delta float64
+
+	// Ввод контроллеров движения перед тиком
+	Inputs []TraceInput
+
+	// Изменения, внесенные игрой после прошлого тика
+	Changes TraceChanges
+
+	// Изменения, внесенные во время тика обработчиками OnPositionUpdated и обработчиками событий
+	PositionHandlerChanges TraceChanges
+	EventHandlerChanges    TraceChanges
+
+	// Состояние тел после тика
+	Bodies []TraceBodyState
+
+	// Пары пересекавшихся тел (номера тел)
+	Pairs [][2]int
+}
+
+// TraceInput - ввод контроллера движения перед тиком
+type TraceInput struct {
+	Input  MovementInput
+	Flying bool
+
+	// Оставшееся время запомненного прыжка
+	JumpBuffer float32
+}
+
+// TraceChanges - изменения тел и снарядов, внесенные вне движка: импульсы, взрывы, силы и перемещения,
+// заданные игрой, выпущенные и удаленные снаряды
+type TraceChanges struct {
+	Bodies []TraceChange
+
+	// Снаряды после изменения (nil - снаряды не менялись)
+	Projectiles []*Projectile
+}
+
+// TraceChange - измененное состояние тела
+type TraceChange struct {
+	// Номер тела
+	Body int
+
+	// Динамическое состояние тела в формате снимка
+	State *RigidBody
+}
+
+// TraceBodyState - состояние тела после тика
+type TraceBodyState struct {
+	Position mgl32.Vec3
+	Velocity mgl32.Vec3
+
+	// Сумма сил, приложенных к телу за тик (гравитация, выталкивающая сила, внешние силы)
+	Force mgl32.Vec3
+
+	Grounded bool
+	InFluid  bool
+	Climbing bool
+	Sleeping bool
+
+	Orientation     mgl32.Quat
+	AngularVelocity mgl32.Vec3
+
+	// Грани блоков, которых касалось тело (биты 1 << Face)
+	Contacts uint8
+}
+
+// TraceDivergence описывает первое расхождение повторной симуляции с журналом
+type TraceDivergence struct {
+	Tick uint64
+
+	// Номер тела (-1, если различается количество тел) и название различающегося поля
+	Body  int
+	Field string
+
+	Recorded TraceBodyState
+	Actual   TraceBodyState
+}
+
+// String возвращает описание расхождения
+func (d *TraceDivergence) String() string {
+	if d.Body < 0 {
+		return fmt.Sprintf("тик %d: %s", d.Tick, d.Field)
+	}
+	return fmt.Sprintf("тик %d, тело %d, поле %s: записано %+v, получено %+v", d.Tick, d.Body, d.Field, d.Recorded, d.Actual)
+}
+
+// traceRecorder записывает тики в журнал
+type traceRecorder struct {
+	w   io.Writer
+	err error
+
+	// Тела на момент начала записи, их состояние и снаряды в формате снимка на момент,
+	// с которого ищутся изменения вне движка
+	bodies      []*RigidBody
+	states      [][]byte
+	projectiles []byte
+
+	// Запись текущего тика, заполняемая в начале тика
+	record snapshotWriter
+}
+
+// StartTrace начинает запись журнала симуляции: заголовок с параметрами тел, контроллеров, датчиков и объемов сил
+// и снимок начального состояния, затем на каждом тике ввод контроллеров, состояние тел, приложенные
+// силы и контакты. Изменения тел и снарядов, внесенные игрой между тиками и обработчиками во время тика
+// (импульсы, взрывы, выпущенные снаряды), записываются вместе с тиком. Если меняется состав тел,
+// запись прекращается с ошибкой, которую возвращают TraceError и StopTrace; записанные до этого тики
+// остаются в журнале. Мир в журнал не входит.
+func (p *PhysicsEngine) StartTrace(w io.Writer) error {
+	header := &snapshotWriter{}
+	header.buf.WriteString(traceMagic)
+	header.u8(traceVersion)
+	header.u32(uint32(p.SleepTicks))
+	header.f32(p.SleepVelocity)
+
+	header.u32(uint32(len(p.bodies)))
+	for _, body := range p.bodies {
+		header.params(body)
+	}
+
+	index := p.bodyIndex()
+	header.u32(uint32(len(p.controllers)))
+	for _, c := range p.controllers {
+		header.u32(uint32(index[c.Body]))
+		header.controllerParams(c)
+	}
+
+	header.u32(uint32(len(p.sensors)))
+	for _, sensor := range p.sensors {
+		header.u32(uint32(len(sensor.Name)))
+		header.buf.WriteString(sensor.Name)
+		header.vec(sensor.Box.Min)
+		header.vec(sensor.Box.Max)
+	}
+
+	header.u32(uint32(len(p.volumes)))
+	for _, volume := range p.volumes {
+		header.forceVolume(volume)
+	}
+
+	snapshot := p.Snapshot()
+	header.u32(uint32(len(snapshot)))
+	header.buf.Write(snapshot)
+
+	if _, err := w.Write(header.buf.Bytes()); err != nil {
+		return fmt.Errorf("Ошибка записи журнала физики: %v", err)
+	}
+	p.trace = &traceRecorder{w: w, bodies: p.Bodies()}
+	p.trace.saveStates(p)
+	return nil
+}
+
+// TraceError возвращает ошибку, прервавшую запись журнала, или nil
+func (p *PhysicsEngine) TraceError() error {
+	if p.trace == nil {
+		return nil
+	}
+	return p.trace.err
+}
+
+// StopTrace завершает запись журнала и возвращает первую ошибку записи
+func (p *PhysicsEngine) StopTrace() error {
+	if p.trace == nil {
+		return nil
+	}
+	err := p.trace.err
+	p.trace = nil
+	if err != nil {
+		return fmt.Errorf("Ошибка записи журнала физики: %v", err)
+	}
+	return nil
+}
+
+// beginTick записывает номер тика, ввод контроллеров и изменения тел после прошлого тика
+func (t *traceRecorder) beginTick(p *PhysicsEngine, delta float64) {
+	if !t.checkBodies(p) {
+		return
+	}
+	t.record.buf.Reset()
+	t.record.u64(p.tick)
+	t.record.u64(math.Float64bits(delta))
+
+	t.record.u32(uint32(len(p.controllers)))
+	for _, c := range p.controllers {
+		t.record.input(c.Input)
+		t.record.bool(c.Flying)
+		t.record.f32(c.jumpBufferTimer)
+	}
+
+	t.writeChanges(p)
+}
+
+// beginHandlers запоминает состояние тел и снарядов перед вызовом обработчиков
+func (p *PhysicsEngine) beginHandlers() {
+	if p.trace != nil && p.trace.err == nil {
+		p.trace.saveStates(p)
+	}
+}
+
+// traceReplay - повторяемый тик журнала
+type traceReplay struct {
+	tick *TraceTick
+
+	// Тела журнала и соответствующие им тела движка для владельцев снарядов
+	bodies map[*RigidBody]*RigidBody
+}
+
+// endHandlers записывает в журнал изменения, внесенные обработчиками,
+// а при повторе журнала применяет записанные изменения
+func (p *PhysicsEngine) endHandlers(handlers int) {
+	if p.trace != nil && p.trace.err == nil {
+		p.trace.writeChanges(p)
+	}
+	if p.replay == nil {
+		return
+	}
+	if handlers == tracePositionHandlers {
+		p.replay.apply(p, p.replay.tick.PositionHandlerChanges)
+	} else {
+		p.replay.apply(p, p.replay.tick.EventHandlerChanges)
+	}
+}
+
+// apply применяет записанные изменения тел и снарядов.
+// Номера тел проверяются при чтении журнала.
+func (r *traceReplay) apply(p *PhysicsEngine, changes TraceChanges) {
+	for _, change := range changes.Bodies {
+		body := p.bodies[change.Body]
+		state := *change.State
+		state.Platform = body.Platform
+		body.restore(&state)
+	}
+	if changes.Projectiles != nil {
+		projectiles := make([]*Projectile, len(changes.Projectiles))
+		for i, pr := range changes.Projectiles {
+			copied := *pr
+			copied.Owner = r.bodies[pr.Owner]
+			projectiles[i] = &copied
+		}
+		p.adoptProjectiles(projectiles)
+	}
+}
+
+// endTick записывает состояние тел и контакты и отправляет запись в журнал.
+// Обработчики событий могли изменить состав тел во время тика - тогда тик не записывается.
+func (t *traceRecorder) endTick(p *PhysicsEngine) {
+	if !t.checkBodies(p) {
+		return
+	}
+	t.record.u32(uint32(len(p.bodies)))
+	for _, body := range p.bodies {
+		t.record.bodyState(traceState(body))
+	}
+
+	index := p.bodyIndex()
+	t.record.u32(uint32(len(p.pairs)))
+	for _, pair := range p.pairs {
+		t.record.u32(uint32(index[pair.A]))
+		t.record.u32(uint32(index[pair.B]))
+	}
+
+	_, t.err = t.w.Write(t.record.buf.Bytes())
+	t.saveStates(p)
+}
+
+// checkBodies проверяет, что запись продолжается и состав тел не изменился с ее начала
+func (t *traceRecorder) checkBodies(p *PhysicsEngine) bool {
+	if t.err != nil {
+		return false
+	}
+	same := len(p.bodies) == len(t.bodies)
+	for i := 0; same && i < len(p.bodies); i++ {
+		same = p.bodies[i] == t.bodies[i]
+	}
+	if !same {
+		t.err = fmt.Errorf("Состав тел изменился на тике %d: записано %d тел, зарегистрировано %d", p.tick, len(t.bodies), len(p.bodies))
+	}
+	return same
+}
+
+// saveStates запоминает состояние тел и снарядов, чтобы найти внесенные вне движка изменения
+func (t *traceRecorder) saveStates(p *PhysicsEngine) {
+	t.states = make([][]byte, len(t.bodies))
+	for i, body := range t.bodies {
+		state := &snapshotWriter{}
+		state.body(body)
+		t.states[i] = state.buf.Bytes()
+	}
+	t.projectiles = projectileState(p)
+}
+
+// writeChanges записывает тела и снаряды, изменившиеся с последнего вызова saveStates.
+// Снаряды записываются целиком, если изменился хотя бы один из них.
+func (t *traceRecorder) writeChanges(p *PhysicsEngine) {
+	var changes []int
+	state := &snapshotWriter{}
+	for i, body := range t.bodies {
+		state.buf.Reset()
+		state.body(body)
+		if !bytes.Equal(state.buf.Bytes(), t.states[i]) {
+			changes = append(changes, i)
+		}
+	}
+	t.record.u32(uint32(len(changes)))
+	for _, i := range changes {
+		t.record.u32(uint32(i))
+		t.record.body(t.bodies[i])
+	}
+
+	projectiles := projectileState(p)
+	changed := !bytes.Equal(projectiles, t.projectiles)
+	t.record.bool(changed)
+	if changed {
+		t.record.buf.Write(projectiles)
+	}
+}
+
+// projectileState возвращает снаряды движка в формате снимка
+func projectileState(p *PhysicsEngine) []byte {
+	w := &snapshotWriter{}
+	index := p.bodyIndex()
+	w.u32(uint32(len(p.projectiles)))
+	for _, pr := range p.projectiles {
+		w.projectile(pr, index)
+	}
+	return w.buf.Bytes()
+}
+
+// traceState возвращает записываемое состояние тела
+func traceState(body *RigidBody) TraceBodyState {
+	return TraceBodyState{
+		Position: body.Position,
+		Velocity: body.Velocity,
+		Force:    body.appliedForce,
+		Grounded: body.Grounded,
+		InFluid:  body.InFluid,
+		Climbing: body.Climbing,
+		Sleeping: body.Sleeping,
+		Contacts: body.contactFaces,
+
+		Orientation:     body.Orientation,
+		AngularVelocity: body.AngularVelocity,
+	}
+}
+
+// ReadTrace читает журнал, записанный методом StartTrace
+func ReadTrace(r io.Reader) (*Trace, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка чтения журнала физики: %v", err)
+	}
+	tr := &snapshotReader{r: bytes.NewReader(data)}
+
+	magic := make([]byte, len(traceMagic))
+	tr.read(magic)
+	if tr.err != nil || string(magic) != traceMagic {
+		return nil, fmt.Errorf("Неверная сигнатура журнала физики")
+	}
+	if version := tr.u8(); version != traceVersion {
+		return nil, fmt.Errorf("Неподдерживаемая версия журнала физики: %d", version)
+	}
+
+	trace := &Trace{
+		SleepTicks:    int(tr.u32()),
+		SleepVelocity: tr.f32(),
+	}
+	trace.Bodies = make([]*RigidBody, tr.count(1))
+	for i := range trace.Bodies {
+		trace.Bodies[i] = tr.params()
+	}
+	trace.Controllers = make([]*MovementController, tr.count(4))
+	for i := range trace.Controllers {
+		body := tr.bodyRef(trace.Bodies)
+		trace.Controllers[i] = tr.controllerParams(body)
+	}
+	trace.Sensors = make([]*Sensor, tr.count(4))
+	for i := range trace.Sensors {
+		name := make([]byte, tr.count(1))
+		tr.read(name)
+		trace.Sensors[i] = NewSensor(string(name), Box{Min: tr.vec(), Max: tr.vec()})
+	}
+	trace.ForceVolumes = make([]*ForceVolume, tr.count(4))
+	for i := range trace.ForceVolumes {
+		trace.ForceVolumes[i] = tr.forceVolume()
+	}
+	trace.Initial = make(Snapshot, tr.count(1))
+	tr.read(trace.Initial)
+	if tr.err != nil {
+		return nil, fmt.Errorf("Ошибка чтения заголовка журнала физики: %v", tr.err)
+	}
+
+	for tr.r.Len() > 0 {
+		tick := tr.tick(trace.Bodies)
+		if tr.err != nil {
+			return nil, fmt.Errorf("Ошибка чтения журнала физики после тика %d: %v", len(trace.Ticks), tr.err)
+		}
+		trace.Ticks = append(trace.Ticks, tick)
+	}
+
+	return trace, nil
+}
+
+// NewEngine создает движок с телами, контроллерами, датчиками и объемами сил журнала в начальном состоянии записи.
+// Тела, двигавшиеся по траектории, повторяют записанные положения.
+func (t *Trace) NewEngine(world CollisionWorld) (*PhysicsEngine, error) {
+	p := NewPhysicsEngine(world)
+	p.SleepTicks = t.SleepTicks
+	p.SleepVelocity = t.SleepVelocity
+
+	bodies := make(map[*RigidBody]*RigidBody, len(t.Bodies))
+	for _, params := range t.Bodies {
+		body := *params
+		bodies[params] = &body
+		p.Register(&body)
+	}
+	for _, params := range t.Controllers {
+		c := *params
+		c.Body = bodies[params.Body]
+		p.RegisterController(&c)
+	}
+	for _, sensor := range t.Sensors {
+		p.RegisterSensor(NewSensor(sensor.Name, sensor.Box))
+	}
+	for _, params := range t.ForceVolumes {
+		volume := *params
+		p.RegisterForceVolume(&volume)
+	}
+
+	if err := p.Restore(t.Initial); err != nil {
+		return nil, err
+	}
+
+	for i, body := range p.bodies {
+		if body.Path != nil {
+			body.Path = t.recordedPath(i, body)
+		}
+	}
+	return p, nil
+}
+
+// Replay повторяет записанную симуляцию с тем же вводом в новом движке и сравнивает состояние тел
+// после каждого тика с журналом. Возвращает первое расхождение больше epsilon или nil.
+func (t *Trace) Replay(world CollisionWorld, epsilon float32) (*TraceDivergence, error) {
+	p, err := t.NewEngine(world)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &traceReplay{bodies: make(map[*RigidBody]*RigidBody, len(t.Bodies))}
+	for i, body := range t.Bodies {
+		replay.bodies[body] = p.bodies[i]
+	}
+
+	for _, tick := range t.Ticks {
+		if len(tick.Inputs) != len(p.controllers) {
+			return nil, fmt.Errorf("Тик %d содержит ввод %d контроллеров, зарегистрировано %d", tick.Tick, len(tick.Inputs), len(p.controllers))
+		}
+		for i, c := range p.controllers {
+			c.Input = tick.Inputs[i].Input
+			c.Flying = tick.Inputs[i].Flying
+			c.jumpBufferTimer = tick.Inputs[i].JumpBuffer
+		}
+		replay.tick = &tick
+		replay.apply(p, tick.Changes)
+
+		// Изменения, внесенные обработчиками, применяются в тех же местах тика
+		p.replay = replay
+		p.Tick(tick.Delta)
+		p.replay = nil
+
+		if len(tick.Bodies) != len(p.bodies) {
+			return &TraceDivergence{
+				Tick:  tick.Tick,
+				Body:  -1,
+				Field: fmt.Sprintf("записано %d тел, получено %d", len(tick.Bodies), len(p.bodies)),
+			}, nil
+		}
+		if !p.samePairs(tick.Pairs) {
+			return &TraceDivergence{Tick: tick.Tick, Body: -1, Field: "различаются пары пересекающихся тел"}, nil
+		}
+		for i, body := range p.bodies {
+			actual := traceState(body)
+			if field := tick.Bodies[i].diff(actual, epsilon); field != "" {
+				return &TraceDivergence{
+					Tick:     tick.Tick,
+					Body:     i,
+					Field:    field,
+					Recorded: tick.Bodies[i],
+					Actual:   actual,
+				}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// samePairs проверяет, совпадают ли пары пересекавшихся тел с записанными
+func (p *PhysicsEngine) samePairs(pairs [][2]int) bool {
+	if len(pairs) != len(p.pairs) {
+		return false
+	}
+	index := p.bodyIndex()
+	for i, pair := range p.pairs {
+		if pairs[i] != [2]int{index[pair.A], index[pair.B]} {
+			return false
+		}
+	}
+	return true
+}
+
+// diff возвращает название первого поля, различающегося больше чем на epsilon, или пустую строку
+func (s TraceBodyState) diff(other TraceBodyState, epsilon float32) string {
+	switch {
+	case !vecClose(s.Position, other.Position, epsilon):
+		return "Position"
+	case !vecClose(s.Velocity, other.Velocity, epsilon):
+		return "Velocity"
+	case !vecClose(s.Force, other.Force, epsilon):
+		return "Force"
+	case s.Grounded != other.Grounded:
+		return "Grounded"
+	case s.InFluid != other.InFluid:
+		return "InFluid"
+	case s.Climbing != other.Climbing:
+		return "Climbing"
+	case s.Sleeping != other.Sleeping:
+		return "Sleeping"
+	case s.Contacts != other.Contacts:
+		return "Contacts"
+	case !quatClose(s.Orientation, other.Orientation, epsilon):
+		return "Orientation"
+	case !vecClose(s.AngularVelocity, other.AngularVelocity, epsilon):
+		return "AngularVelocity"
+	}
+	return ""
+}
+
+// vecClose проверяет, что компоненты векторов различаются не больше чем на epsilon
+func vecClose(a, b mgl32.Vec3, epsilon float32) bool {
+	for i := range a {
+		if float32(math.Abs(float64(a[i]-b[i]))) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// quatClose проверяет, что компоненты кватернионов различаются не больше чем на epsilon
+func quatClose(a, b mgl32.Quat, epsilon float32) bool {
+	return vecClose(a.V, b.V, epsilon) && float32(math.Abs(float64(a.W-b.W))) <= epsilon
+}
+
+// recordedPath строит траекторию кинематического тела по записанным положениям
+func (t *Trace) recordedPath(index int, body *RigidBody) *tracePath {
+	path := &tracePath{start: body.Position}
+	time := body.pathTime
+	for _, tick := range t.Ticks {
+		if index >= len(tick.Bodies) {
+			break
+		}
+		time += tick.Delta
+		path.times = append(path.times, time)
+		path.positions = append(path.positions, tick.Bodies[index].Position)
+	}
+	return path
+}
+
+// tracePath - траектория, повторяющая записанные положения
+type tracePath struct {
+	start     mgl32.Vec3
+	times     []float64
+	positions []mgl32.Vec3
+}
+
+// PositionAt возвращает записанное положение на момент t
+func (p *tracePath) PositionAt(t float64) mgl32.Vec3 {
+	i := sort.SearchFloat64s(p.times, t)
+	if i < len(p.positions) {
+		return p.positions[i]
+	}
+	if len(p.positions) > 0 {
+		return p.positions[len(p.positions)-1]
+	}
+	return p.start
+}
+
+// params записывает параметры тела
+func (w *snapshotWriter) params(body *RigidBody) {
+	flags := uint8(0)
+	if body.Kinematic {
+		flags |= traceKinematic
+	}
+	if body.Path != nil {
+		flags |= traceHasPath
+	}
+	if body.Rotates {
+		flags |= traceRotates
+	}
+	w.u8(flags)
+
+	w.f32(body.Mass)
+	w.f32(body.Width)
+	w.f32(body.Height)
+	w.f32(body.JumpSpeed)
+	w.vec(body.Gravity)
+	w.f32(body.PenetrationEpsilonSmall)
+	w.f32(body.PenetrationEpsilonBig)
+	w.f32(body.AirMovementSuppression)
+	w.f32(body.FlyingSpeedMultipier)
+	w.u32(uint32(body.PositionHistoryLength))
+	w.f32(body.AirDrag)
+	w.f32(body.StepHeight)
+	w.f32(body.Buoyancy)
+	w.f32(body.MaxClimbFall)
+	w.vec(body.Inertia)
+	w.f32(body.AngularDamping)
+}
+
+// controllerParams записывает параметры контроллера движения
+func (w *snapshotWriter) controllerParams(c *MovementController) {
+	w.f32(c.Speed)
+	w.f32(c.JumpForce)
+	w.f32(c.SprintSpeed)
+	w.f32(c.CrouchSpeed)
+	w.f32(c.SwimSpeed)
+	w.f32(c.ClimbSpeed)
+	w.f32(c.StandingHeight)
+	w.f32(c.CrouchHeight)
+	w.f32(c.GroundAcceleration)
+	w.f32(c.GroundDeceleration)
+	w.f32(c.AirAcceleration)
+	w.f32(c.AirDeceleration)
+	w.f32(c.CoyoteTime)
+	w.f32(c.JumpBufferTime)
+}
+
+// forceVolume записывает параметры объема сил
+func (w *snapshotWriter) forceVolume(v *ForceVolume) {
+	w.u32(uint32(len(v.Name)))
+	w.buf.WriteString(v.Name)
+	w.vec(v.Box.Min)
+	w.vec(v.Box.Max)
+	w.u32(uint32(int32(v.Priority)))
+	w.u8(uint8(v.Blend))
+	w.vec(v.Acceleration)
+	w.f32(v.Scale)
+	w.vec(v.Carry)
+	w.vec(v.Launch)
+}
+
+// input записывает ввод контроллера движения
+func (w *snapshotWriter) input(input MovementInput) {
+	w.f32(input.Forward)
+	w.f32(input.Right)
+	w.f32(input.Up)
+	w.bool(input.Jump)
+	w.bool(input.Sprint)
+	w.bool(input.Crouch)
+	w.vec(input.ViewVector)
+	w.vec(input.RightVector)
+}
+
+// bodyState записывает состояние тела после тика
+func (w *snapshotWriter) bodyState(s TraceBodyState) {
+	w.vec(s.Position)
+	w.vec(s.Velocity)
+	w.vec(s.Force)
+	w.bool(s.Grounded)
+	w.bool(s.InFluid)
+	w.bool(s.Climbing)
+	w.bool(s.Sleeping)
+	w.u8(s.Contacts)
+	w.quat(s.Orientation)
+	w.vec(s.AngularVelocity)
+}
+
+// params читает параметры тела, записанные snapshotWriter.params
+func (r *snapshotReader) params() *RigidBody {
+	flags := r.u8()
+	body := NewRigidBody(mgl32.Vec3{}, 0, 0, 0)
+	body.Kinematic = flags&traceKinematic != 0
+	body.Rotates = flags&traceRotates != 0
+	if flags&traceHasPath != 0 {
+		// Настоящая траектория заменяется записанной в Trace.NewEngine
+		body.Path = &tracePath{}
+	}
+
+	body.Mass = r.f32()
+	body.Width = r.f32()
+	body.Height = r.f32()
+	body.JumpSpeed = r.f32()
+	body.Gravity = r.vec()
+	body.PenetrationEpsilonSmall = r.f32()
+	body.PenetrationEpsilonBig = r.f32()
+	body.AirMovementSuppression = r.f32()
+	body.FlyingSpeedMultipier = r.f32()
+	body.PositionHistoryLength = int(r.u32())
+	body.AirDrag = r.f32()
+	body.StepHeight = r.f32()
+	body.Buoyancy = r.f32()
+	body.MaxClimbFall = r.f32()
+	body.Inertia = r.vec()
+	body.AngularDamping = r.f32()
+	return body
+}
+
+// controllerParams читает параметры контроллера, записанные snapshotWriter.controllerParams
+func (r *snapshotReader) controllerParams(body *RigidBody) *MovementController {
+	return &MovementController{
+		Body:               body,
+		Speed:              r.f32(),
+		JumpForce:          r.f32(),
+		SprintSpeed:        r.f32(),
+		CrouchSpeed:        r.f32(),
+		SwimSpeed:          r.f32(),
+		ClimbSpeed:         r.f32(),
+		StandingHeight:     r.f32(),
+		CrouchHeight:       r.f32(),
+		GroundAcceleration: r.f32(),
+		GroundDeceleration: r.f32(),
+		AirAcceleration:    r.f32(),
+		AirDeceleration:    r.f32(),
+		CoyoteTime:         r.f32(),
+		JumpBufferTime:     r.f32(),
+	}
+}
+
+// forceVolume читает параметры объема сил, записанные snapshotWriter.forceVolume
+func (r *snapshotReader) forceVolume() *ForceVolume {
+	name := make([]byte, r.count(1))
+	r.read(name)
+	v := NewForceVolume(string(name), Box{Min: r.vec(), Max: r.vec()}, VolumeAdd)
+	v.Priority = int(int32(r.u32()))
+	v.Blend = VolumeBlend(r.u8())
+	v.Acceleration = r.vec()
+	v.Scale = r.f32()
+	v.Carry = r.vec()
+	v.Launch = r.vec()
+	return v
+}
+
+// input читает ввод контроллера, записанный snapshotWriter.input
+func (r *snapshotReader) input() MovementInput {
+	return MovementInput{
+		Forward:     r.f32(),
+		Right:       r.f32(),
+		Up:          r.f32(),
+		Jump:        r.bool(),
+		Sprint:      r.bool(),
+		Crouch:      r.bool(),
+		ViewVector:  r.vec(),
+		RightVector: r.vec(),
+	}
+}
+
+// bodyState читает состояние тела, записанное snapshotWriter.bodyState
+func (r *snapshotReader) bodyState() TraceBodyState {
+	return TraceBodyState{
+		Position: r.vec(),
+		Velocity: r.vec(),
+		Force:    r.vec(),
+		Grounded: r.bool(),
+		InFluid:  r.bool(),
+		Climbing: r.bool(),
+		Sleeping: r.bool(),
+		Contacts: r.u8(),
+
+		Orientation:     r.quat(),
+		AngularVelocity: r.vec(),
+	}
+}
+
+// changes читает изменения, записанные traceRecorder.writeChanges, для заданных тел журнала
+func (r *snapshotReader) changes(bodies []*RigidBody) TraceChanges {
+	var changes TraceChanges
+	changes.Bodies = make([]TraceChange, r.count(4))
+	for i := range changes.Bodies {
+		change := TraceChange{Body: int(r.u32()), State: &RigidBody{}}
+		if r.err == nil && change.Body >= len(bodies) {
+			r.err = fmt.Errorf("номер тела вне диапазона: %d", change.Body)
+		}
+		r.body(change.State)
+		changes.Bodies[i] = change
+	}
+
+	if r.bool() {
+		changes.Projectiles = make([]*Projectile, r.count(4))
+		for i := range changes.Projectiles {
+			changes.Projectiles[i] = r.projectile(bodies)
+		}
+	}
+	return changes
+}
+
+// tick читает запись тика для заданных тел журнала
+func (r *snapshotReader) tick(bodies []*RigidBody) TraceTick {
+	tick := TraceTick{
+		Tick:  r.u64(),
+		Delta: math.Float64frombits(r.u64()),
+	}
+
+	tick.Inputs = make([]TraceInput, r.count(1))
+	for i := range tick.Inputs {
+		tick.Inputs[i] = TraceInput{
+			Input:      r.input(),
+			Flying:     r.bool(),
+			JumpBuffer: r.f32(),
+		}
+	}
+
+	tick.Changes = r.changes(bodies)
+	tick.PositionHandlerChanges = r.changes(bodies)
+	tick.EventHandlerChanges = r.changes(bodies)
+
+	tick.Bodies = make([]TraceBodyState, r.count(1))
+	for i := range tick.Bodies {
+		tick.Bodies[i] = r.bodyState()
+	}
+
+	tick.Pairs = make([][2]int, r.count(8))
+	for i := range tick.Pairs {
+		tick.Pairs[i] = [2]int{int(r.u32()), int(r.u32())}
+	}
+	return tick
+}
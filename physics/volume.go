@@ -0,0 +1,196 @@
+package physics
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VolumeBlend определяет, как объем сил сочетается с гравитацией тела и объемами меньшего приоритета
+type VolumeBlend uint8
+
+const (
+	// VolumeAdd прибавляет ускорение, перенос и запуск объема к накопленным
+	VolumeAdd VolumeBlend = iota
+	// VolumeOverride заменяет ими гравитацию тела и действие объемов меньшего приоритета
+	VolumeOverride
+	// VolumeScale умножает на Scale гравитацию тела и действие объемов меньшего приоритета
+	VolumeScale
+)
+
+// ForceVolume - область, меняющая гравитацию тел внутри и прикладывающая к ним силы:
+// зоны пониженной гравитации, аэродинамические трубы, прыжковые платформы, конвейеры.
+// Объемы применяются в порядке возрастания Priority, объемы равного приоритета - в порядке регистрации.
+// Ускорение смешивается пропорционально доле объема тела внутри области, поэтому на границе зоны
+// гравитация меняется плавно. Перенос и запуск действуют на стоящие тела пропорционально доле их опоры в области.
+//
+// Низом для опоры, приземления, отскока, подъема на ступень, удержания у края, сна и жидкостей всегда
+// считается -Y: гравитацией служит вертикальная составляющая ускорения, а горизонтальные составляющие
+// действуют на тело как ветер. Поле, направленное вверх, поднимает тела, но не ставит их на потолок.
+type ForceVolume struct {
+	Name string
+	Box  Box
+
+	Priority int
+	Blend    VolumeBlend
+
+	// Ускорение внутри объема (м/с²): гравитация зоны для VolumeOverride, ветер для VolumeAdd
+	Acceleration mgl32.Vec3
+	// Множитель для VolumeScale
+	Scale float32
+
+	// Скорость, с которой объем переносит стоящие в нем тела (конвейер)
+	Carry mgl32.Vec3
+	// Скорость, которую получает стоящее в объеме тело (прыжковая платформа)
+	Launch mgl32.Vec3
+}
+
+// NewForceVolume создает объем сил с заданной областью и правилом смешивания
+func NewForceVolume(name string, box Box, blend VolumeBlend) *ForceVolume {
+	return &ForceVolume{
+		Name:  name,
+		Box:   box,
+		Blend: blend,
+		Scale: 1,
+	}
+}
+
+// RegisterForceVolume добавляет объем сил в движок и пробуждает тела в нем
+func (p *PhysicsEngine) RegisterForceVolume(volume *ForceVolume) {
+	for _, v := range p.volumes {
+		if v == volume {
+			return
+		}
+	}
+	p.volumes = append(p.volumes, volume)
+	p.WakeRegion(volume.Box)
+}
+
+// UnregisterForceVolume удаляет объем сил из движка и пробуждает тела в нем
+func (p *PhysicsEngine) UnregisterForceVolume(volume *ForceVolume) {
+	for i, v := range p.volumes {
+		if v == volume {
+			p.volumes = append(p.volumes[:i], p.volumes[i+1:]...)
+			p.WakeRegion(volume.Box)
+			return
+		}
+	}
+}
+
+// ForceVolumes возвращает зарегистрированные объемы сил в порядке регистрации
+func (p *PhysicsEngine) ForceVolumes() []*ForceVolume {
+	volumes := make([]*ForceVolume, len(p.volumes))
+	copy(volumes, p.volumes)
+	return volumes
+}
+
+// sortedVolumes возвращает объемы сил в порядке применения
+func (p *PhysicsEngine) sortedVolumes() []*ForceVolume {
+	volumes := p.ForceVolumes()
+	sort.SliceStable(volumes, func(i, j int) bool {
+		return volumes[i].Priority < volumes[j].Priority
+	})
+	return volumes
+}
+
+// volumeEffect - совместное действие гравитации и объемов сил на тело
+type volumeEffect struct {
+	acceleration mgl32.Vec3
+	carry        mgl32.Vec3
+	launch       mgl32.Vec3
+}
+
+// blend сочетает действие объема с накопленным. weight - доля тела в объеме, support - доля его опоры.
+func (e *volumeEffect) blend(v *ForceVolume, weight, support float32) {
+	switch v.Blend {
+	case VolumeAdd:
+		e.acceleration = e.acceleration.Add(v.Acceleration.Mul(weight))
+		e.carry = e.carry.Add(v.Carry.Mul(support))
+		e.launch = e.launch.Add(v.Launch.Mul(support))
+	case VolumeOverride:
+		e.acceleration = lerpVec(e.acceleration, v.Acceleration, weight)
+		e.carry = lerpVec(e.carry, v.Carry, support)
+		e.launch = lerpVec(e.launch, v.Launch, support)
+	case VolumeScale:
+		e.acceleration = e.acceleration.Mul(1 + (v.Scale-1)*weight)
+		e.carry = e.carry.Mul(1 + (v.Scale-1)*support)
+		e.launch = e.launch.Mul(1 + (v.Scale-1)*support)
+	}
+}
+
+// bodyEffect вычисляет действие гравитации тела и объемов сил на тело.
+// Перенос и запуск действуют только на стоящие тела; на летящее тело объемы не действуют.
+func bodyEffect(body *RigidBody, volumes []*ForceVolume) volumeEffect {
+	if body.Flying {
+		return volumeEffect{}
+	}
+	effect := volumeEffect{acceleration: body.Gravity}
+	if body.Collider == nil {
+		return effect
+	}
+
+	collider := *body.Collider
+	feet := collider
+	feet.Max[1] = feet.Min.Y() + groundProbeDepth
+	for _, v := range volumes {
+		if !collider.Overlaps(v.Box) {
+			continue
+		}
+		support := float32(0)
+		if body.Grounded {
+			support = overlapFraction(feet, v.Box)
+		}
+		effect.blend(v, overlapFraction(collider, v.Box), support)
+	}
+	return effect
+}
+
+// fieldAt возвращает ускорение, которое сообщают точке гравитация gravity и объемы сил
+func fieldAt(point, gravity mgl32.Vec3, volumes []*ForceVolume) mgl32.Vec3 {
+	effect := volumeEffect{acceleration: gravity}
+	for _, v := range volumes {
+		if v.Box.Contains(point) {
+			effect.blend(v, 1, 0)
+		}
+	}
+	return effect.acceleration
+}
+
+// applyVolumeMotion переносит стоящее тело конвейером и подбрасывает прыжковой платформой.
+// Запуск заменяет составляющую скорости тела вдоль направления запуска.
+func applyVolumeMotion(body *RigidBody, effect volumeEffect, delta float64) {
+	if !body.Grounded {
+		return
+	}
+	if effect.carry != (mgl32.Vec3{}) {
+		body.Movement = body.Movement.Add(effect.carry.Mul(float32(delta)))
+	}
+	if speed := effect.launch.Len(); speed > 0 {
+		direction := effect.launch.Mul(1 / speed)
+		body.Velocity = body.Velocity.Sub(direction.Mul(body.Velocity.Dot(direction))).Add(effect.launch)
+		body.Grounded = false
+	}
+}
+
+// overlapFraction возвращает долю бокса, лежащую внутри области.
+// Плоский бокс считается внутри, если внутри его центр.
+func overlapFraction(box, region Box) float32 {
+	volume := box.Volume()
+	if volume == 0 {
+		if region.Contains(box.Center()) {
+			return 1
+		}
+		return 0
+	}
+	return minf(box.Intersect(region).Volume()/volume, 1)
+}
+
+// verticalField возвращает вертикальную составляющую поля - гравитацию, направленную вдоль оси Y
+func verticalField(field mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{0, field.Y(), 0}
+}
+
+// lerpVec линейно интерполирует между векторами
+func lerpVec(a, b mgl32.Vec3, t float32) mgl32.Vec3 {
+	return a.Add(b.Sub(a).Mul(t))
+}
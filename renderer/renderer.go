@@ -194,7 +194,8 @@ func (r *Renderer) DrawChunk(chunk *world.Chunk) {
 				if block != nil && block.Active {
 					// Создаем матрицу модели для блока
 					modelLoc := gl.GetUniformLocation(r.shader, gl.Str("model\x00"))
-					blockPos := block.Position
+					// Блок занимает ячейку от Position до Position+1, а куб отрисовывается вокруг центра
+					blockPos := block.Position.Add(mgl32.Vec3{0.5, 0.5, 0.5})
 					blockModel := mgl32.Translate3D(blockPos.X(), blockPos.Y(), blockPos.Z()).Mul4(
 						mgl32.Scale3D(0.98, 0.98, 0.98)) // Чуть меньше 1, чтобы были видны грани
 					gl.UniformMatrix4fv(modelLoc, 1, false, &blockModel[0])
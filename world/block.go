@@ -0,0 +1,489 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/user/gengine/physics"
+)
+
+// StateID идентифицирует конкретное состояние блока в реестре. 0 соответствует воздуху.
+type StateID uint32
+
+// AirStateID идентификатор состояния воздуха (пустого блока)
+const AirStateID StateID = 0
+
+// Property описывает свойство состояния блока и его допустимые значения.
+// Первое значение используется по умолчанию.
+type Property struct {
+	Name   string
+	Values []string
+}
+
+// Стандартные свойства для ориентируемых блоков
+var (
+	PropertyFacing      = Property{Name: "facing", Values: []string{"north", "south", "west", "east"}}
+	PropertyAxis        = Property{Name: "axis", Values: []string{"y", "x", "z"}}
+	PropertyHalf        = Property{Name: "half", Values: []string{"bottom", "top"}}
+	PropertyDoorHalf    = Property{Name: "half", Values: []string{"lower", "upper"}}
+	PropertySlabType    = Property{Name: "type", Values: []string{"bottom", "top", "double"}}
+	PropertyWaterlogged = Property{Name: "waterlogged", Values: []string{"false", "true"}}
+	PropertyOpen        = Property{Name: "open", Values: []string{"false", "true"}}
+)
+
+// indexOf возвращает индекс значения свойства или -1
+func (p Property) indexOf(value string) int {
+	for i, v := range p.Values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// BlockDefinition описывает тип блока и набор свойств его состояний
+type BlockDefinition struct {
+	Name       string
+	Properties []Property
+
+	// Material описывает свойства поверхности блока. Если не задан, используется physics.DefaultMaterial.
+	Material *physics.Material
+
+	// Fluid задается для блоков жидкостей, заполняющих всю ячейку
+	Fluid *physics.Fluid
+
+	// Climbable - по блоку можно карабкаться (лестницы, лианы, строительные леса)
+	Climbable bool
+
+	// Falls - блок падает, лишившись опоры снизу (песок, гравий)
+	Falls bool
+
+	// BlastResistance - стойкость к взрывам. Отрицательное значение делает блок неразрушимым.
+	BlastResistance float32
+
+	// Shape возвращает формы коллизии состояния в локальных координатах блока (0..1).
+	// Если не задана, блок считается полным кубом.
+	Shape func(state BlockState) []physics.Box
+
+	// Первый идентификатор состояния, выделенный реестром
+	firstState StateID
+	// Количество состояний блока
+	stateCount uint32
+	// Формы коллизии и жидкости (nil - без жидкости), вычисленные для каждого состояния при регистрации
+	shapes [][]physics.Box
+	fluids []*physics.Fluid
+}
+
+// BlockState представляет блок вместе со значениями его свойств.
+// Отсутствующие свойства принимают значения по умолчанию.
+type BlockState struct {
+	Block      string
+	Properties map[string]string
+}
+
+// NewBlockState создает состояние блока со свойствами по умолчанию
+func NewBlockState(block string) BlockState {
+	return BlockState{Block: block}
+}
+
+// With возвращает копию состояния с измененным значением свойства
+func (s BlockState) With(name, value string) BlockState {
+	props := make(map[string]string, len(s.Properties)+1)
+	for k, v := range s.Properties {
+		props[k] = v
+	}
+	props[name] = value
+	return BlockState{Block: s.Block, Properties: props}
+}
+
+// Get возвращает значение свойства состояния или пустую строку
+func (s BlockState) Get(name string) string {
+	return s.Properties[name]
+}
+
+// IsAir проверяет, является ли состояние воздухом
+func (s BlockState) IsAir() bool {
+	return s.Block == "" || s.Block == "air"
+}
+
+// String возвращает текстовое представление состояния, например "oak_stairs[facing=east,half=bottom]"
+func (s BlockState) String() string {
+	if len(s.Properties) == 0 {
+		return s.Block
+	}
+
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+s.Properties[k])
+	}
+	return s.Block + "[" + strings.Join(parts, ",") + "]"
+}
+
+// ParseBlockState разбирает текстовое представление состояния блока
+func ParseBlockState(text string) (BlockState, error) {
+	open := strings.IndexByte(text, '[')
+	if open < 0 {
+		return BlockState{Block: text}, nil
+	}
+	if !strings.HasSuffix(text, "]") {
+		return BlockState{}, fmt.Errorf("Некорректное состояние блока: %q", text)
+	}
+
+	state := BlockState{
+		Block:      text[:open],
+		Properties: make(map[string]string),
+	}
+	body := text[open+1 : len(text)-1]
+	if body == "" {
+		return state, nil
+	}
+	for _, part := range strings.Split(body, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return BlockState{}, fmt.Errorf("Некорректное свойство %q в состоянии %q", part, text)
+		}
+		state.Properties[kv[0]] = kv[1]
+	}
+	return state, nil
+}
+
+// BlockRegistry хранит определения блоков и выделяет идентификаторы их состояний
+type BlockRegistry struct {
+	definitions map[string]*BlockDefinition
+	// Определения в порядке выделения идентификаторов
+	ordered   []*BlockDefinition
+	nextState StateID
+	mutex     sync.RWMutex
+}
+
+// NewBlockRegistry создает реестр, содержащий только воздух
+func NewBlockRegistry() *BlockRegistry {
+	r := &BlockRegistry{
+		definitions: make(map[string]*BlockDefinition),
+	}
+	r.Register(&BlockDefinition{Name: "air", Shape: EmptyShape})
+	return r
+}
+
+// Материалы поверхностей стандартных блоков
+var (
+	MaterialIce      = physics.Material{Friction: 0.5, Bounciness: 0, SpeedFactor: 1}
+	MaterialSlime    = physics.Material{Friction: physics.DefaultFriction, Bounciness: 0.8, SpeedFactor: 1}
+	MaterialSoulSand = physics.Material{Friction: physics.DefaultFriction, Bounciness: 0, SpeedFactor: 0.4}
+)
+
+// Свойства стандартных жидкостей
+var (
+	FluidWater = physics.Fluid{Density: 1, Drag: 3}
+	FluidLava  = physics.Fluid{Density: 1.2, Drag: 8}
+)
+
+// DefaultRegistry реестр блоков, используемый чанками и миром
+var DefaultRegistry = newDefaultRegistry()
+
+// newDefaultRegistry создает реестр со стандартным набором блоков
+func newDefaultRegistry() *BlockRegistry {
+	r := NewBlockRegistry()
+
+	r.Register(&BlockDefinition{Name: "stone", BlastResistance: 6})
+	r.Register(&BlockDefinition{Name: "brick", BlastResistance: 6})
+	r.Register(&BlockDefinition{Name: "dirt", BlastResistance: 0.5})
+	r.Register(&BlockDefinition{Name: "grass", BlastResistance: 0.6})
+	r.Register(&BlockDefinition{Name: "sand", Falls: true, BlastResistance: 0.5})
+	r.Register(&BlockDefinition{Name: "gravel", Falls: true, BlastResistance: 0.6})
+	r.Register(&BlockDefinition{Name: "oak_planks", BlastResistance: 3})
+	r.Register(&BlockDefinition{
+		Name:            "oak_log",
+		Properties:      []Property{PropertyAxis},
+		BlastResistance: 2,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "oak_stairs",
+		Properties:      []Property{PropertyFacing, PropertyHalf, PropertyWaterlogged},
+		Shape:           StairsShape,
+		BlastResistance: 3,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "stone_slab",
+		Properties:      []Property{PropertySlabType, PropertyWaterlogged},
+		Shape:           SlabShape,
+		BlastResistance: 6,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "oak_door",
+		Properties:      []Property{PropertyFacing, PropertyDoorHalf, PropertyOpen},
+		Shape:           DoorShape,
+		BlastResistance: 3,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "chest",
+		Properties:      []Property{PropertyFacing, PropertyWaterlogged},
+		Shape:           ChestShape,
+		BlastResistance: 2.5,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "oak_fence",
+		Shape:           FenceShape,
+		BlastResistance: 3,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "carpet",
+		Shape:           CarpetShape,
+		BlastResistance: 0.1,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "ice",
+		Material:        &MaterialIce,
+		BlastResistance: 0.5,
+	})
+	r.Register(&BlockDefinition{
+		Name:     "slime",
+		Material: &MaterialSlime,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "soul_sand",
+		Material:        &MaterialSoulSand,
+		Shape:           SoulSandShape,
+		BlastResistance: 0.5,
+	})
+	r.Register(&BlockDefinition{
+		Name:  "tall_grass",
+		Shape: EmptyShape,
+	})
+	r.Register(&BlockDefinition{
+		Name:  "flower",
+		Shape: EmptyShape,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "ladder",
+		Properties:      []Property{PropertyFacing, PropertyWaterlogged},
+		Shape:           LadderShape,
+		Climbable:       true,
+		BlastResistance: 0.4,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "vine",
+		Shape:           EmptyShape,
+		Climbable:       true,
+		BlastResistance: 0.2,
+	})
+	r.Register(&BlockDefinition{
+		Name:       "scaffolding",
+		Properties: []Property{PropertyWaterlogged},
+		Shape:      EmptyShape,
+		Climbable:  true,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "water",
+		Fluid:           &FluidWater,
+		Shape:           EmptyShape,
+		BlastResistance: 100,
+	})
+	r.Register(&BlockDefinition{
+		Name:            "lava",
+		Fluid:           &FluidLava,
+		Shape:           EmptyShape,
+		BlastResistance: 100,
+	})
+
+	return r
+}
+
+// Register добавляет определение блока и выделяет идентификаторы для всех его состояний
+func (r *BlockRegistry) Register(def *BlockDefinition) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.register(def)
+}
+
+// register добавляет определение без блокировки
+func (r *BlockRegistry) register(def *BlockDefinition) error {
+	if _, exists := r.definitions[def.Name]; exists {
+		return fmt.Errorf("Блок %q уже зарегистрирован", def.Name)
+	}
+
+	count := uint32(1)
+	for _, prop := range def.Properties {
+		if len(prop.Values) == 0 {
+			return fmt.Errorf("Свойство %q блока %q не имеет значений", prop.Name, def.Name)
+		}
+		count *= uint32(len(prop.Values))
+	}
+
+	def.firstState = r.nextState
+	def.stateCount = count
+	r.nextState += StateID(count)
+
+	// Заранее вычисляем формы коллизии и жидкости всех состояний
+	def.shapes = make([][]physics.Box, count)
+	def.fluids = make([]*physics.Fluid, count)
+	for offset := uint32(0); offset < count; offset++ {
+		state := def.stateAt(offset)
+		if def.Shape == nil {
+			def.shapes[offset] = FullCubeShape(BlockState{})
+		} else {
+			def.shapes[offset] = def.Shape(state)
+		}
+		if fluid, ok := def.FluidOf(state); ok {
+			def.fluids[offset] = &fluid
+		}
+	}
+
+	r.definitions[def.Name] = def
+	r.ordered = append(r.ordered, def)
+	return nil
+}
+
+// Get возвращает определение блока по имени или nil
+func (r *BlockRegistry) Get(name string) *BlockDefinition {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.definitions[name]
+}
+
+// StateID возвращает идентификатор состояния блока
+func (r *BlockRegistry) StateID(state BlockState) (StateID, error) {
+	if state.IsAir() {
+		return AirStateID, nil
+	}
+
+	def := r.Get(state.Block)
+	if def == nil {
+		return 0, fmt.Errorf("Неизвестный блок %q", state.Block)
+	}
+
+	for name := range state.Properties {
+		if !def.hasProperty(name) {
+			return 0, fmt.Errorf("Блок %q не имеет свойства %q", def.Name, name)
+		}
+	}
+
+	// Идентификатор вычисляется как число в смешанной системе счисления по значениям свойств
+	offset := uint32(0)
+	for _, prop := range def.Properties {
+		idx := 0
+		if value, ok := state.Properties[prop.Name]; ok {
+			idx = prop.indexOf(value)
+			if idx < 0 {
+				return 0, fmt.Errorf("Недопустимое значение %q свойства %q блока %q", value, prop.Name, def.Name)
+			}
+		}
+		offset = offset*uint32(len(prop.Values)) + uint32(idx)
+	}
+
+	return def.firstState + StateID(offset), nil
+}
+
+// State возвращает состояние блока по идентификатору
+func (r *BlockRegistry) State(id StateID) (BlockState, bool) {
+	def := r.Definition(id)
+	if def == nil {
+		return BlockState{}, false
+	}
+	if id == AirStateID {
+		return BlockState{}, true
+	}
+
+	return def.stateAt(uint32(id - def.firstState)), true
+}
+
+// CollisionShapes возвращает формы коллизии состояния в локальных координатах блока.
+// Для воздуха и неизвестных состояний возвращает nil.
+func (r *BlockRegistry) CollisionShapes(id StateID) []physics.Box {
+	if id == AirStateID {
+		return nil
+	}
+	def := r.Definition(id)
+	if def == nil {
+		return nil
+	}
+	return def.shapes[id-def.firstState]
+}
+
+// Fluid возвращает жидкость, заполняющую ячейку блока в заданном состоянии
+func (r *BlockRegistry) Fluid(id StateID) (physics.Fluid, bool) {
+	if id == AirStateID {
+		return physics.Fluid{}, false
+	}
+	def := r.Definition(id)
+	if def == nil || def.fluids[id-def.firstState] == nil {
+		return physics.Fluid{}, false
+	}
+	return *def.fluids[id-def.firstState], true
+}
+
+// Definition возвращает определение блока, которому принадлежит состояние, или nil
+func (r *BlockRegistry) Definition(id StateID) *BlockDefinition {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Определения упорядочены по возрастанию идентификаторов
+	i := sort.Search(len(r.ordered), func(i int) bool {
+		def := r.ordered[i]
+		return def.firstState+StateID(def.stateCount) > id
+	})
+	if i == len(r.ordered) {
+		return nil
+	}
+	return r.ordered[i]
+}
+
+// FluidOf возвращает жидкость, заполняющую ячейку блока в заданном состоянии.
+// Блоки со свойством waterlogged=true заполнены водой.
+func (def *BlockDefinition) FluidOf(state BlockState) (physics.Fluid, bool) {
+	if def.Fluid != nil {
+		return *def.Fluid, true
+	}
+	if def.hasProperty(PropertyWaterlogged.Name) && state.Get(PropertyWaterlogged.Name) == "true" {
+		return FluidWater, true
+	}
+	return physics.Fluid{}, false
+}
+
+// SurfaceMaterial возвращает материал поверхности блока
+func (def *BlockDefinition) SurfaceMaterial() physics.Material {
+	if def.Material == nil {
+		return physics.DefaultMaterial
+	}
+	return *def.Material
+}
+
+// DefaultStateID возвращает идентификатор состояния блока по умолчанию
+func (def *BlockDefinition) DefaultStateID() StateID {
+	return def.firstState
+}
+
+// stateAt восстанавливает состояние по смещению относительно первого идентификатора блока
+func (def *BlockDefinition) stateAt(offset uint32) BlockState {
+	state := BlockState{Block: def.Name}
+	if len(def.Properties) > 0 {
+		state.Properties = make(map[string]string, len(def.Properties))
+		for i := len(def.Properties) - 1; i >= 0; i-- {
+			prop := def.Properties[i]
+			n := uint32(len(prop.Values))
+			state.Properties[prop.Name] = prop.Values[offset%n]
+			offset /= n
+		}
+	}
+	return state
+}
+
+// hasProperty проверяет, объявлено ли свойство в определении блока
+func (def *BlockDefinition) hasProperty(name string) bool {
+	for _, prop := range def.Properties {
+		if prop.Name == name {
+			return true
+		}
+	}
+	return false
+}
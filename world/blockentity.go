@@ -0,0 +1,72 @@
+package world
+
+import (
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// BlockPos представляет собой целочисленные координаты блока
+type BlockPos struct {
+	X, Y, Z int
+}
+
+// BlockEntity хранит дополнительное состояние блока (содержимое сундука, текст таблички и т.п.)
+type BlockEntity struct {
+	// Тип блок-сущности
+	Type string
+
+	// Мировая позиция блока, которому принадлежит сущность
+	Position mgl32.Vec3
+
+	// Данные сущности
+	Data *TagCompound
+}
+
+// BlockEntityType описывает тип блок-сущности и обработчики ее жизненного цикла
+type BlockEntityType struct {
+	Name string
+
+	// OnCreate вызывается при создании сущности, например для заполнения данных по умолчанию
+	OnCreate func(*BlockEntity)
+
+	// OnRemove вызывается при удалении сущности вместе с блоком
+	OnRemove func(*BlockEntity)
+}
+
+var (
+	blockEntityTypes      = make(map[string]*BlockEntityType)
+	blockEntityTypesMutex sync.RWMutex
+)
+
+// RegisterBlockEntityType связывает тип блока с типом блок-сущности.
+// После регистрации установка блока этого типа автоматически создает сущность.
+func RegisterBlockEntityType(blockType string, entityType *BlockEntityType) {
+	blockEntityTypesMutex.Lock()
+	defer blockEntityTypesMutex.Unlock()
+
+	blockEntityTypes[blockType] = entityType
+}
+
+// GetBlockEntityType возвращает тип блок-сущности для типа блока или nil
+func GetBlockEntityType(blockType string) *BlockEntityType {
+	blockEntityTypesMutex.RLock()
+	defer blockEntityTypesMutex.RUnlock()
+
+	return blockEntityTypes[blockType]
+}
+
+// NewBlockEntity создает новую блок-сущность и вызывает обработчик создания
+func NewBlockEntity(entityType *BlockEntityType, position mgl32.Vec3) *BlockEntity {
+	e := &BlockEntity{
+		Type:     entityType.Name,
+		Position: position,
+		Data:     NewTagCompound(),
+	}
+
+	if entityType.OnCreate != nil {
+		entityType.OnCreate(e)
+	}
+
+	return e
+}
@@ -1,6 +1,8 @@
 package world
 
 import (
+	"fmt"
+
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/user/gengine/physics"
 )
@@ -15,6 +17,7 @@ const (
 type BlockData struct {
 	Active    bool
 	BlockType string
+	State     StateID
 	Position  mgl32.Vec3
 }
 
@@ -25,12 +28,16 @@ type Chunk struct {
 
 	// Позиция чанка в мире (угол)
 	Position mgl32.Vec3
+
+	// Блок-сущности по локальным координатам внутри чанка
+	BlockEntities map[BlockPos]*BlockEntity
 }
 
 // NewChunk создает новый чанк с заданной позицией
 func NewChunk(pos mgl32.Vec3) *Chunk {
 	c := &Chunk{
-		Position: pos,
+		Position:      pos,
+		BlockEntities: make(map[BlockPos]*BlockEntity),
 	}
 
 	// Инициализируем все блоки как неактивные
@@ -61,13 +68,100 @@ func (c *Chunk) GetBlock(x, y, z int) *BlockData {
 	return c.Blocks[x][y][z]
 }
 
-// SetBlock устанавливает блок по локальным координатам чанка
-func (c *Chunk) SetBlock(x, y, z int, blockType string, active bool) {
+// SetBlock устанавливает блок по локальным координатам чанка.
+// Активный блок получает состояние по умолчанию для своего типа; тип должен быть зарегистрирован.
+func (c *Chunk) SetBlock(x, y, z int, blockType string, active bool) error {
+	if x < 0 || x >= ChunkWidth || y < 0 || y >= ChunkHeight || z < 0 || z >= ChunkWidth {
+		return nil
+	}
+
+	state := AirStateID
+	if active {
+		def := DefaultRegistry.Get(blockType)
+		if def == nil {
+			return fmt.Errorf("Неизвестный блок %q", blockType)
+		}
+		state = def.DefaultStateID()
+	}
+	c.setBlock(x, y, z, blockType, state, active)
+	return nil
+}
+
+// SetBlockState устанавливает состояние блока по локальным координатам чанка
+func (c *Chunk) SetBlockState(x, y, z int, state StateID) {
+	if x < 0 || x >= ChunkWidth || y < 0 || y >= ChunkHeight || z < 0 || z >= ChunkWidth {
+		return
+	}
+
+	def := DefaultRegistry.Definition(state)
+	if state == AirStateID || def == nil {
+		c.setBlock(x, y, z, "", AirStateID, false)
+		return
+	}
+	c.setBlock(x, y, z, def.Name, state, true)
+}
+
+// GetBlockState возвращает состояние блока по локальным координатам чанка
+func (c *Chunk) GetBlockState(x, y, z int) BlockState {
+	block := c.GetBlock(x, y, z)
+	if block == nil || !block.Active {
+		return BlockState{}
+	}
+	state, _ := DefaultRegistry.State(block.State)
+	return state
+}
+
+// setBlock обновляет данные блока и управляет жизненным циклом блок-сущности
+func (c *Chunk) setBlock(x, y, z int, blockType string, state StateID, active bool) {
+	block := c.Blocks[x][y][z]
+	if block.BlockType == blockType && block.Active == active {
+		// Тот же блок в другом состоянии (например, открытая дверь) сохраняет свою сущность
+		block.State = state
+		return
+	}
+
+	// Удаляем сущность прежнего блока
+	c.RemoveBlockEntity(x, y, z)
+
+	block.BlockType = blockType
+	block.State = state
+	block.Active = active
+
+	// Создаем сущность, если она предусмотрена для нового типа блока
+	if active {
+		if entityType := GetBlockEntityType(blockType); entityType != nil {
+			c.BlockEntities[BlockPos{x, y, z}] = NewBlockEntity(entityType, block.Position)
+		}
+	}
+}
+
+// GetBlockEntity возвращает блок-сущность по локальным координатам чанка или nil
+func (c *Chunk) GetBlockEntity(x, y, z int) *BlockEntity {
+	return c.BlockEntities[BlockPos{x, y, z}]
+}
+
+// SetBlockEntity устанавливает блок-сущность по локальным координатам чанка без вызова обработчиков.
+// Используется при загрузке чанка.
+func (c *Chunk) SetBlockEntity(x, y, z int, entity *BlockEntity) {
 	if x < 0 || x >= ChunkWidth || y < 0 || y >= ChunkHeight || z < 0 || z >= ChunkWidth {
 		return
 	}
-	c.Blocks[x][y][z].BlockType = blockType
-	c.Blocks[x][y][z].Active = active
+	entity.Position = c.Blocks[x][y][z].Position
+	c.BlockEntities[BlockPos{x, y, z}] = entity
+}
+
+// RemoveBlockEntity удаляет блок-сущность по локальным координатам чанка и вызывает обработчик удаления
+func (c *Chunk) RemoveBlockEntity(x, y, z int) {
+	key := BlockPos{x, y, z}
+	entity, ok := c.BlockEntities[key]
+	if !ok {
+		return
+	}
+	delete(c.BlockEntities, key)
+
+	if entityType := GetBlockEntityType(c.Blocks[x][y][z].BlockType); entityType != nil && entityType.OnRemove != nil {
+		entityType.OnRemove(entity)
+	}
 }
 
 // GetBlockFromWorldPos возвращает блок по мировым координатам
@@ -80,6 +174,15 @@ func (c *Chunk) GetBlockFromWorldPos(pos mgl32.Vec3) *BlockData {
 	return c.GetBlock(localX, localY, localZ)
 }
 
+// GetBlockEntityFromWorldPos возвращает блок-сущность по мировым координатам
+func (c *Chunk) GetBlockEntityFromWorldPos(pos mgl32.Vec3) *BlockEntity {
+	localX := int(pos.X() - c.Position.X())
+	localY := int(pos.Y() - c.Position.Y())
+	localZ := int(pos.Z() - c.Position.Z())
+
+	return c.GetBlockEntity(localX, localY, localZ)
+}
+
 // GetBoundingBox возвращает ограничивающий бокс чанка
 func (c *Chunk) GetBoundingBox() physics.Box {
 	return physics.Box{
@@ -0,0 +1,293 @@
+package world
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/physics"
+)
+
+// World реализует физический мир для движка
+var (
+	_ physics.CollisionWorld = (*World)(nil)
+	_ physics.FluidWorld     = (*World)(nil)
+	_ physics.ClimbableWorld = (*World)(nil)
+	_ physics.ExplosionWorld = (*World)(nil)
+)
+
+// MaxRaycastDistance - наибольшая дальность луча в блоках
+const MaxRaycastDistance = 1024
+
+// RaycastHit описывает попадание луча в форму блока
+type RaycastHit struct {
+	// Координаты блока, в который попал луч
+	Block BlockPos
+
+	// Точка попадания и нормаль грани
+	Position mgl32.Vec3
+	Normal   mgl32.Vec3
+
+	// Расстояние от начала луча до точки попадания
+	Distance float32
+}
+
+// GetBlockAt возвращает блок по целочисленным мировым координатам
+func (w *World) GetBlockAt(x, y, z int) *BlockData {
+	chunk, localX, localZ := w.chunkAt(x, z)
+	if chunk == nil {
+		return nil
+	}
+	return chunk.GetBlock(localX, y, localZ)
+}
+
+// chunkAt возвращает чанк, содержащий столбец блоков, и локальные координаты столбца в нем
+func (w *World) chunkAt(x, z int) (*Chunk, int, int) {
+	chunkX := floorDiv(x, ChunkWidth) * ChunkWidth
+	chunkZ := floorDiv(z, ChunkWidth) * ChunkWidth
+
+	w.chunksMutex.RLock()
+	chunk := w.chunks[GetChunkKey(mgl32.Vec3{float32(chunkX), 0, float32(chunkZ)})]
+	w.chunksMutex.RUnlock()
+
+	return chunk, x - chunkX, z - chunkZ
+}
+
+// BlockShapes возвращает формы коллизии блока в мировых координатах
+func (w *World) BlockShapes(x, y, z int) []physics.Box {
+	block := w.GetBlockAt(x, y, z)
+	if block == nil || !block.Active {
+		return nil
+	}
+
+	local := DefaultRegistry.CollisionShapes(block.State)
+	shapes := make([]physics.Box, 0, len(local))
+	offset := mgl32.Vec3{float32(x), float32(y), float32(z)}
+	for _, shape := range local {
+		shapes = append(shapes, physics.Box{
+			Min: shape.Min.Add(offset),
+			Max: shape.Max.Add(offset),
+		})
+	}
+	return shapes
+}
+
+// CollisionBoxes возвращает формы коллизии блоков в мировых координатах, пересекающие область
+func (w *World) CollisionBoxes(region physics.Box) []physics.Box {
+	minX, minY, minZ := floorInt(region.Min.X()), floorInt(region.Min.Y()), floorInt(region.Min.Z())
+	maxX, maxY, maxZ := floorInt(region.Max.X()), floorInt(region.Max.Y()), floorInt(region.Max.Z())
+
+	// Формы некоторых блоков (заборы) выше одного блока, поэтому захватываем слой снизу
+	minY--
+
+	boxes := make([]physics.Box, 0)
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			for z := minZ; z <= maxZ; z++ {
+				for _, shape := range w.BlockShapes(x, y, z) {
+					if shape.Overlaps(region) {
+						boxes = append(boxes, shape)
+					}
+				}
+			}
+		}
+	}
+
+	return boxes
+}
+
+// MaterialAt возвращает материал блока в ячейке, содержащей точку
+func (w *World) MaterialAt(point mgl32.Vec3) physics.Material {
+	block := w.GetBlockAt(floorInt(point.X()), floorInt(point.Y()), floorInt(point.Z()))
+	if block == nil || !block.Active {
+		return physics.DefaultMaterial
+	}
+
+	def := DefaultRegistry.Definition(block.State)
+	if def == nil {
+		return physics.DefaultMaterial
+	}
+	return def.SurfaceMaterial()
+}
+
+// FluidVolumes возвращает ячейки блоков с жидкостью, пересекающие область
+func (w *World) FluidVolumes(region physics.Box) []physics.FluidVolume {
+	minX, minY, minZ := floorInt(region.Min.X()), floorInt(region.Min.Y()), floorInt(region.Min.Z())
+	maxX, maxY, maxZ := floorInt(region.Max.X()), floorInt(region.Max.Y()), floorInt(region.Max.Z())
+
+	volumes := make([]physics.FluidVolume, 0)
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			for z := minZ; z <= maxZ; z++ {
+				block := w.GetBlockAt(x, y, z)
+				if block == nil || !block.Active {
+					continue
+				}
+				fluid, ok := DefaultRegistry.Fluid(block.State)
+				if !ok {
+					continue
+				}
+
+				cell := shapeBox(0, 0, 0, 1, 1, 1).Translate(mgl32.Vec3{float32(x), float32(y), float32(z)})
+				if cell.Overlaps(region) {
+					volumes = append(volumes, physics.FluidVolume{Box: cell, Fluid: fluid})
+				}
+			}
+		}
+	}
+
+	return volumes
+}
+
+// Climbable проверяет, пересекает ли область ячейку блока, по которому можно карабкаться
+func (w *World) Climbable(region physics.Box) bool {
+	minX, minY, minZ := floorInt(region.Min.X()), floorInt(region.Min.Y()), floorInt(region.Min.Z())
+	maxX, maxY, maxZ := floorInt(region.Max.X()), floorInt(region.Max.Y()), floorInt(region.Max.Z())
+
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			for z := minZ; z <= maxZ; z++ {
+				block := w.GetBlockAt(x, y, z)
+				if block == nil || !block.Active {
+					continue
+				}
+				def := DefaultRegistry.Definition(block.State)
+				if def == nil || !def.Climbable {
+					continue
+				}
+
+				cell := shapeBox(0, 0, 0, 1, 1, 1).Translate(mgl32.Vec3{float32(x), float32(y), float32(z)})
+				if cell.Overlaps(region) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// BlastResistance возвращает стойкость блока к взрыву из реестра
+func (w *World) BlastResistance(block [3]int) (float32, bool) {
+	data := w.GetBlockAt(block[0], block[1], block[2])
+	if data == nil || !data.Active {
+		return 0, false
+	}
+
+	def := DefaultRegistry.Definition(data.State)
+	if def == nil {
+		return 0, false
+	}
+	return def.BlastResistance, true
+}
+
+// DestroyBlock удаляет блок, разрушенный взрывом
+func (w *World) DestroyBlock(block [3]int) {
+	w.setBlockAt(block[0], block[1], block[2], AirStateID)
+}
+
+// Raycast ищет первое попадание луча в формы блоков на расстоянии не дальше maxDistance.
+// Обход ячеек выполняется по алгоритму Amanatides-Woo. Дальность ограничена MaxRaycastDistance,
+// луч с бесконечными или нечисловыми параметрами ни во что не попадает.
+func (w *World) Raycast(origin, direction mgl32.Vec3, maxDistance float32) (RaycastHit, bool) {
+	if !finiteVec(origin) || !finiteVec(direction) || !finite(maxDistance) || maxDistance < 0 || direction.Len() == 0 {
+		return RaycastHit{}, false
+	}
+	direction = direction.Normalize()
+	maxDistance = float32(math.Min(float64(maxDistance), MaxRaycastDistance))
+
+	cell := [3]int{floorInt(origin.X()), floorInt(origin.Y()), floorInt(origin.Z())}
+	var step [3]int
+	var tMax, tDelta [3]float32
+	for axis := 0; axis < 3; axis++ {
+		switch {
+		case direction[axis] > 0:
+			step[axis] = 1
+			tDelta[axis] = 1 / direction[axis]
+			tMax[axis] = (float32(cell[axis]+1) - origin[axis]) / direction[axis]
+		case direction[axis] < 0:
+			step[axis] = -1
+			tDelta[axis] = -1 / direction[axis]
+			tMax[axis] = (float32(cell[axis]) - origin[axis]) / direction[axis]
+		default:
+			tDelta[axis] = float32(math.Inf(1))
+			tMax[axis] = float32(math.Inf(1))
+		}
+	}
+
+	for t := float32(0); t <= maxDistance; {
+		// Выше и ниже мира блоков нет: луч, ушедший за его высоту, уже ни во что не попадет
+		if (cell[1] < 0 && step[1] <= 0) || (cell[1] > ChunkHeight && step[1] >= 0) {
+			break
+		}
+		if hit, ok := w.raycastCell(cell, origin, direction, maxDistance); ok {
+			return hit, true
+		}
+
+		// Переходим в следующую ячейку по оси с ближайшей границей
+		axis := 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		t = tMax[axis]
+		cell[axis] += step[axis]
+		tMax[axis] += tDelta[axis]
+	}
+
+	return RaycastHit{}, false
+}
+
+// raycastCell проверяет попадание луча в формы блока ячейки и высокие формы блока под ней
+func (w *World) raycastCell(cell [3]int, origin, direction mgl32.Vec3, maxDistance float32) (RaycastHit, bool) {
+	best := RaycastHit{Distance: maxDistance}
+	found := false
+
+	test := func(x, y, z int, tallOnly bool) {
+		for _, shape := range w.BlockShapes(x, y, z) {
+			if tallOnly && shape.Max.Y() <= float32(y+1) {
+				continue
+			}
+			t, normal, ok := shape.IntersectRay(origin, direction)
+			if ok && t <= best.Distance {
+				best = RaycastHit{
+					Block:    BlockPos{x, y, z},
+					Position: origin.Add(direction.Mul(t)),
+					Normal:   normal,
+					Distance: t,
+				}
+				found = true
+			}
+		}
+	}
+
+	test(cell[0], cell[1], cell[2], false)
+	test(cell[0], cell[1]-1, cell[2], true)
+
+	return best, found
+}
+
+// finite проверяет, что число конечно
+func finite(v float32) bool {
+	return !math.IsInf(float64(v), 0) && !math.IsNaN(float64(v))
+}
+
+// finiteVec проверяет, что все компоненты вектора конечны
+func finiteVec(v mgl32.Vec3) bool {
+	return finite(v[0]) && finite(v[1]) && finite(v[2])
+}
+
+// floorInt округляет число вниз до целого
+func floorInt(v float32) int {
+	return int(math.Floor(float64(v)))
+}
+
+// floorDiv выполняет целочисленное деление с округлением вниз
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,187 @@
+package world
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/physics"
+)
+
+const (
+	// Масса падающего блока (кг)
+	DefaultFallingBlockMass = 1500.0
+
+	// Ширина тела падающего блока; чуть меньше ячейки, чтобы блок пролетал в проемы шириной в блок.
+	// Высота совпадает с высотой блока, чтобы лежащие на нем тела не оказались внутри установленного блока
+	fallingBlockWidth  = 0.98
+	fallingBlockHeight = 1.0
+)
+
+// FallingBlock - блок, падающий как физическое тело
+type FallingBlock struct {
+	State BlockState
+	Body  *physics.RigidBody
+}
+
+// FallingBlocks переносит лишившиеся опоры падающие блоки из мира в физический движок
+// и устанавливает их обратно в мир после приземления
+type FallingBlocks struct {
+	world  *World
+	engine *physics.PhysicsEngine
+	blocks []*FallingBlock
+
+	// OnLanded вызывается, когда блок установлен в мир в месте приземления
+	OnLanded func(block *FallingBlock, pos BlockPos)
+
+	// OnDropped вызывается, когда блок приземлился на неполный блок или в занятую ячейку
+	// и не может быть установлен (например, для выпадения предмета)
+	OnDropped func(block *FallingBlock, position mgl32.Vec3)
+}
+
+// NewFallingBlocks создает мост между падающими блоками мира и физическим движком.
+// Устанавливает обработчик World.OnBlockFall.
+func NewFallingBlocks(world *World, engine *physics.PhysicsEngine) *FallingBlocks {
+	f := &FallingBlocks{
+		world:  world,
+		engine: engine,
+	}
+	world.OnBlockFall = f.spawn
+	return f
+}
+
+// Blocks возвращает падающие в данный момент блоки
+func (f *FallingBlocks) Blocks() []*FallingBlock {
+	blocks := make([]*FallingBlock, len(f.blocks))
+	copy(blocks, f.blocks)
+	return blocks
+}
+
+// spawn создает тело для блока, удаленного из мира
+func (f *FallingBlocks) spawn(pos BlockPos, state BlockState) {
+	position := mgl32.Vec3{float32(pos.X) + 0.5, float32(pos.Y), float32(pos.Z) + 0.5}
+	block := &FallingBlock{
+		State: state,
+		Body:  physics.NewRigidBody(position, DefaultFallingBlockMass, fallingBlockWidth, fallingBlockHeight),
+	}
+	block.Body.OnPositionUpdated = func(body *physics.RigidBody) {
+		switch {
+		case body.Position.Y() < 0:
+			// Блок выпал за пределы мира
+			f.remove(block)
+		case body.Grounded:
+			f.land(block)
+		}
+	}
+
+	f.blocks = append(f.blocks, block)
+	f.engine.Register(block.Body)
+}
+
+// land устанавливает приземлившийся блок в мир или роняет его.
+// Блок, стоящий на другом теле (например, на падающем ниже блоке), продолжает падение.
+func (f *FallingBlocks) land(block *FallingBlock) {
+	position := block.Body.Position
+	if !f.world.restsOn(position) {
+		return
+	}
+	f.remove(block)
+
+	x, z := floorInt(position.X()), floorInt(position.Z())
+	// Тело стоит на опоре, поэтому ячейка опоры находится чуть ниже ног
+	y := floorInt(position.Y() + physics.CollisionEpsilon)
+	below := floorInt(position.Y() - physics.CollisionEpsilon)
+
+	if y != below+1 || !f.world.isFullCube(x, below, z) || !f.world.isReplaceable(x, y, z) {
+		if f.OnDropped != nil {
+			f.OnDropped(block, position)
+		}
+		return
+	}
+
+	id, err := DefaultRegistry.StateID(block.State)
+	if err != nil {
+		return
+	}
+	f.world.setBlockAt(x, y, z, id)
+
+	// Блоки, падающие следом, могли войти в ячейку на этом тике: ставим их на установленный блок
+	cell := physics.NewBox(mgl32.Vec3{float32(x), float32(y), float32(z)}, mgl32.Vec3{float32(x + 1), float32(y + 1), float32(z + 1)})
+	for _, other := range f.blocks {
+		if other.Body.Collider != nil && other.Body.Collider.Overlaps(cell) {
+			other.Body.Position[1] = cell.Max.Y()
+			other.Body.UpdateCollider()
+		}
+	}
+
+	if f.OnLanded != nil {
+		f.OnLanded(block, BlockPos{x, y, z})
+	}
+}
+
+// remove удаляет падающий блок из движка
+func (f *FallingBlocks) remove(block *FallingBlock) {
+	f.engine.Unregister(block.Body)
+	for i, b := range f.blocks {
+		if b == block {
+			f.blocks = append(f.blocks[:i], f.blocks[i+1:]...)
+			return
+		}
+	}
+}
+
+// checkFall удаляет из мира падающий блок без опоры и передает его обработчику OnBlockFall
+func (w *World) checkFall(x, y, z int) {
+	if w.OnBlockFall == nil {
+		return
+	}
+
+	block := w.GetBlockAt(x, y, z)
+	if block == nil || !block.Active {
+		return
+	}
+	def := DefaultRegistry.Definition(block.State)
+	if def == nil || !def.Falls {
+		return
+	}
+
+	// Ячейки вне мира и незагруженных чанков считаются опорой
+	below := w.GetBlockAt(x, y-1, z)
+	if below == nil || (below.Active && len(DefaultRegistry.CollisionShapes(below.State)) > 0) {
+		return
+	}
+
+	// Соседей оповещаем после создания падающего блока, чтобы столб падал снизу вверх
+	state, _ := DefaultRegistry.State(block.State)
+	chunk, localX, localZ := w.chunkAt(x, z)
+	chunk.SetBlockState(localX, y, localZ, AirStateID)
+	w.OnBlockFall(BlockPos{x, y, z}, state)
+	w.neighbourChanged(x, y, z)
+}
+
+// restsOn проверяет, касается ли точка верхней грани формы блока
+func (w *World) restsOn(point mgl32.Vec3) bool {
+	probe := physics.NewBox(point, point).Expand(mgl32.Vec3{0, physics.CollisionEpsilon, 0})
+	for _, box := range w.CollisionBoxes(probe) {
+		if box.Max.Y() <= probe.Max.Y() {
+			return true
+		}
+	}
+	return false
+}
+
+// isFullCube проверяет, занимает ли форма блока всю ячейку
+func (w *World) isFullCube(x, y, z int) bool {
+	block := w.GetBlockAt(x, y, z)
+	if block == nil || !block.Active {
+		return false
+	}
+	shapes := DefaultRegistry.CollisionShapes(block.State)
+	return len(shapes) == 1 && shapes[0] == shapeBox(0, 0, 0, 1, 1, 1)
+}
+
+// isReplaceable проверяет, можно ли установить блок в ячейку (воздух и блоки без коллизии)
+func (w *World) isReplaceable(x, y, z int) bool {
+	block := w.GetBlockAt(x, y, z)
+	if block == nil {
+		return false
+	}
+	return !block.Active || len(DefaultRegistry.CollisionShapes(block.State)) == 0
+}
@@ -0,0 +1,285 @@
+package world
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// Сигнатура и версия формата сериализации чанка
+	chunkMagic   = "GECH"
+	chunkVersion = 1
+
+	// Шаблон имени файла чанка в каталоге мира
+	chunkFilePattern = "chunk_*.bin"
+)
+
+// Serialize записывает чанк в двоичном виде вместе с блок-сущностями.
+// Состояния блоков хранятся в палитре в текстовом виде, каждый блок ссылается на индекс палитры (0 - воздух).
+func (c *Chunk) Serialize(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := io.WriteString(bw, chunkMagic); err != nil {
+		return err
+	}
+	if err := writeUint8(bw, chunkVersion); err != nil {
+		return err
+	}
+
+	// Позиция чанка
+	for i := 0; i < 3; i++ {
+		if err := writeUint32(bw, math.Float32bits(c.Position[i])); err != nil {
+			return err
+		}
+	}
+
+	// Строим палитру состояний блоков
+	palette := []StateID{AirStateID}
+	paletteIndex := map[StateID]uint16{AirStateID: 0}
+	indices := make([]uint16, 0, ChunkWidth*ChunkHeight*ChunkWidth)
+	for x := 0; x < ChunkWidth; x++ {
+		for y := 0; y < ChunkHeight; y++ {
+			for z := 0; z < ChunkWidth; z++ {
+				block := c.Blocks[x][y][z]
+				if !block.Active {
+					indices = append(indices, 0)
+					continue
+				}
+
+				idx, ok := paletteIndex[block.State]
+				if !ok {
+					if len(palette) > math.MaxUint16 {
+						return fmt.Errorf("Слишком много состояний блоков в чанке")
+					}
+					idx = uint16(len(palette))
+					palette = append(palette, block.State)
+					paletteIndex[block.State] = idx
+				}
+				indices = append(indices, idx)
+			}
+		}
+	}
+
+	// Палитра
+	if err := writeUint16(bw, uint16(len(palette))); err != nil {
+		return err
+	}
+	for _, id := range palette {
+		state, _ := DefaultRegistry.State(id)
+		if err := writeString(bw, state.String()); err != nil {
+			return err
+		}
+	}
+
+	// Блоки
+	for _, idx := range indices {
+		if err := writeUint16(bw, idx); err != nil {
+			return err
+		}
+	}
+
+	// Блок-сущности в детерминированном порядке
+	positions := make([]BlockPos, 0, len(c.BlockEntities))
+	for pos := range c.BlockEntities {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+
+	if err := writeUint32(bw, uint32(len(positions))); err != nil {
+		return err
+	}
+	for _, pos := range positions {
+		entity := c.BlockEntities[pos]
+		if err := writeUint8(bw, uint8(pos.X)); err != nil {
+			return err
+		}
+		if err := writeUint8(bw, uint8(pos.Y)); err != nil {
+			return err
+		}
+		if err := writeUint8(bw, uint8(pos.Z)); err != nil {
+			return err
+		}
+		if err := writeString(bw, entity.Type); err != nil {
+			return err
+		}
+		if _, err := entity.Data.WriteTo(bw); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// DeserializeChunk читает чанк, записанный методом Serialize.
+// Обработчики создания блок-сущностей не вызываются - данные восстанавливаются как есть.
+// Чанк с блоками, отсутствующими в реестре, не загружается.
+func DeserializeChunk(r io.Reader) (*Chunk, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(chunkMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != chunkMagic {
+		return nil, fmt.Errorf("Неверная сигнатура чанка: %q", magic)
+	}
+	version, err := readUint8(br)
+	if err != nil {
+		return nil, err
+	}
+	if version != chunkVersion {
+		return nil, fmt.Errorf("Неподдерживаемая версия чанка: %d", version)
+	}
+
+	// Позиция чанка
+	var pos mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		bits, err := readUint32(br)
+		if err != nil {
+			return nil, err
+		}
+		pos[i] = math.Float32frombits(bits)
+	}
+	c := NewChunk(pos)
+
+	// Палитра
+	paletteSize, err := readUint16(br)
+	if err != nil {
+		return nil, err
+	}
+	// Идентификаторы состояний зависят от реестра, поэтому палитра разрешается заново
+	palette := make([]StateID, paletteSize)
+	for i := range palette {
+		text, err := readString(br)
+		if err != nil {
+			return nil, err
+		}
+		state, err := ParseBlockState(text)
+		if err != nil {
+			return nil, err
+		}
+		// Неизвестные блоки не регистрируются: общий реестр не должен зависеть от загруженных файлов
+		if palette[i], err = DefaultRegistry.StateID(state); err != nil {
+			return nil, err
+		}
+	}
+
+	// Блоки
+	for x := 0; x < ChunkWidth; x++ {
+		for y := 0; y < ChunkHeight; y++ {
+			for z := 0; z < ChunkWidth; z++ {
+				idx, err := readUint16(br)
+				if err != nil {
+					return nil, err
+				}
+				if idx == 0 {
+					continue
+				}
+				if int(idx) >= len(palette) {
+					return nil, fmt.Errorf("Индекс палитры вне диапазона: %d", idx)
+				}
+				// Данные блока заполняются напрямую, чтобы не создавать сущности по умолчанию
+				block := c.Blocks[x][y][z]
+				block.BlockType = DefaultRegistry.Definition(palette[idx]).Name
+				block.State = palette[idx]
+				block.Active = true
+			}
+		}
+	}
+
+	// Блок-сущности
+	entityCount, err := readUint32(br)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < entityCount; i++ {
+		var local [3]uint8
+		for j := range local {
+			if local[j], err = readUint8(br); err != nil {
+				return nil, err
+			}
+		}
+		entityType, err := readString(br)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ReadTagCompound(br)
+		if err != nil {
+			return nil, err
+		}
+
+		c.SetBlockEntity(int(local[0]), int(local[1]), int(local[2]), &BlockEntity{
+			Type: entityType,
+			Data: data,
+		})
+	}
+
+	return c, nil
+}
+
+// Save записывает все чанки мира в каталог, по одному файлу на чанк
+func (w *World) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, chunk := range w.GetAllChunks() {
+		name := filepath.Join(dir, "chunk_"+GetChunkKey(chunk.Position)+".bin")
+		if err := saveChunk(name, chunk); err != nil {
+			return fmt.Errorf("Ошибка сохранения чанка %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// saveChunk записывает чанк в файл
+func saveChunk(name string, chunk *Chunk) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := chunk.Serialize(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadWorld загружает мир из каталога, записанного методом Save
+func LoadWorld(dir string) (*World, error) {
+	names, err := filepath.Glob(filepath.Join(dir, chunkFilePattern))
+	if err != nil {
+		return nil, err
+	}
+
+	w := NewWorld()
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := DeserializeChunk(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Ошибка загрузки чанка %s: %v", name, err)
+		}
+		w.AddChunk(chunk)
+	}
+	return w, nil
+}
@@ -0,0 +1,132 @@
+package world
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/physics"
+)
+
+// Толщина двери и размеры столбика забора в долях блока
+const (
+	doorThickness = 3.0 / 16.0
+	ladderDepth   = 3.0 / 16.0
+	fenceMin      = 6.0 / 16.0
+	fenceMax      = 10.0 / 16.0
+	fenceHeight   = 1.5
+	carpetHeight  = 1.0 / 16.0
+)
+
+// shapeBox создает бокс формы в локальных координатах блока
+func shapeBox(minX, minY, minZ, maxX, maxY, maxZ float32) physics.Box {
+	return physics.NewBox(mgl32.Vec3{minX, minY, minZ}, mgl32.Vec3{maxX, maxY, maxZ})
+}
+
+// FullCubeShape возвращает форму полного блока
+func FullCubeShape(state BlockState) []physics.Box {
+	return []physics.Box{shapeBox(0, 0, 0, 1, 1, 1)}
+}
+
+// EmptyShape возвращает пустую форму для блоков без коллизии (растения, воздух)
+func EmptyShape(state BlockState) []physics.Box {
+	return []physics.Box{}
+}
+
+// SlabShape возвращает форму плиты в зависимости от свойства "type"
+func SlabShape(state BlockState) []physics.Box {
+	switch state.Get("type") {
+	case "top":
+		return []physics.Box{shapeBox(0, 0.5, 0, 1, 1, 1)}
+	case "double":
+		return FullCubeShape(state)
+	default:
+		return []physics.Box{shapeBox(0, 0, 0, 1, 0.5, 1)}
+	}
+}
+
+// StairsShape возвращает форму ступеней: половинная плита и ступень со стороны "facing"
+func StairsShape(state BlockState) []physics.Box {
+	// Плита и ступень меняются местами для перевернутых ступеней
+	slab := shapeBox(0, 0, 0, 1, 0.5, 1)
+	stepMinY, stepMaxY := float32(0.5), float32(1)
+	if state.Get("half") == "top" {
+		slab = shapeBox(0, 0.5, 0, 1, 1, 1)
+		stepMinY, stepMaxY = 0, 0.5
+	}
+
+	var step physics.Box
+	switch state.Get("facing") {
+	case "south":
+		step = shapeBox(0, stepMinY, 0.5, 1, stepMaxY, 1)
+	case "west":
+		step = shapeBox(0, stepMinY, 0, 0.5, stepMaxY, 1)
+	case "east":
+		step = shapeBox(0.5, stepMinY, 0, 1, stepMaxY, 1)
+	default:
+		step = shapeBox(0, stepMinY, 0, 1, stepMaxY, 0.5)
+	}
+
+	return []physics.Box{slab, step}
+}
+
+// DoorShape возвращает форму двери - тонкую панель у края блока.
+// Открытая дверь поворачивается на 90 градусов.
+func DoorShape(state BlockState) []physics.Box {
+	facing := state.Get("facing")
+	if state.Get("open") == "true" {
+		facing = rotateClockwise(facing)
+	}
+
+	return []physics.Box{panelBox(facing, doorThickness)}
+}
+
+// LadderShape возвращает форму лестницы - тонкую панель у стены, к которой она приставлена
+func LadderShape(state BlockState) []physics.Box {
+	return []physics.Box{panelBox(state.Get("facing"), ladderDepth)}
+}
+
+// FenceShape возвращает форму столбика забора. Забор выше блока, чтобы через него нельзя было перепрыгнуть.
+func FenceShape(state BlockState) []physics.Box {
+	return []physics.Box{shapeBox(fenceMin, 0, fenceMin, fenceMax, fenceHeight, fenceMax)}
+}
+
+// CarpetShape возвращает форму ковра
+func CarpetShape(state BlockState) []physics.Box {
+	return []physics.Box{shapeBox(0, 0, 0, 1, carpetHeight, 1)}
+}
+
+// SoulSandShape возвращает форму песка душ, чуть ниже полного блока, чтобы тело в него проваливалось
+func SoulSandShape(state BlockState) []physics.Box {
+	return []physics.Box{shapeBox(0, 0, 0, 1, 14.0/16.0, 1)}
+}
+
+// ChestShape возвращает форму сундука, немного меньшую полного блока
+func ChestShape(state BlockState) []physics.Box {
+	return []physics.Box{shapeBox(1.0/16.0, 0, 1.0/16.0, 15.0/16.0, 14.0/16.0, 15.0/16.0)}
+}
+
+// panelBox возвращает панель заданной толщины у края блока, противоположного направлению facing
+func panelBox(facing string, thickness float32) physics.Box {
+	switch facing {
+	case "south":
+		return shapeBox(0, 0, 0, 1, 1, thickness)
+	case "west":
+		return shapeBox(1-thickness, 0, 0, 1, 1, 1)
+	case "east":
+		return shapeBox(0, 0, 0, thickness, 1, 1)
+	default:
+		return shapeBox(0, 0, 1-thickness, 1, 1, 1)
+	}
+}
+
+// rotateClockwise поворачивает направление на 90 градусов по часовой стрелке (вид сверху)
+func rotateClockwise(facing string) string {
+	switch facing {
+	case "north":
+		return "east"
+	case "east":
+		return "south"
+	case "south":
+		return "west"
+	default:
+		return "north"
+	}
+}
@@ -0,0 +1,391 @@
+package world
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// TagType определяет тип значения, хранимого в TagCompound
+type TagType byte
+
+const (
+	TagBool TagType = iota + 1
+	TagInt
+	TagFloat
+	TagString
+	TagCompoundType
+	TagList
+)
+
+// MaxTagDepth - наибольшая вложенность контейнеров тегов при записи и чтении
+const MaxTagDepth = 64
+
+// TagCompound представляет собой типизированный контейнер данных блок-сущности.
+// Значения хранятся по строковым ключам, каждый ключ имеет фиксированный тип.
+type TagCompound struct {
+	tags map[string]interface{}
+}
+
+// NewTagCompound создает пустой контейнер тегов
+func NewTagCompound() *TagCompound {
+	return &TagCompound{
+		tags: make(map[string]interface{}),
+	}
+}
+
+// Has проверяет наличие ключа в контейнере
+func (t *TagCompound) Has(key string) bool {
+	_, ok := t.tags[key]
+	return ok
+}
+
+// Remove удаляет значение по ключу
+func (t *TagCompound) Remove(key string) {
+	delete(t.tags, key)
+}
+
+// Len возвращает количество значений в контейнере
+func (t *TagCompound) Len() int {
+	return len(t.tags)
+}
+
+// Keys возвращает отсортированный список ключей
+func (t *TagCompound) Keys() []string {
+	keys := make([]string, 0, len(t.tags))
+	for key := range t.tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Type возвращает тип значения по ключу, 0 если ключа нет
+func (t *TagCompound) Type(key string) TagType {
+	switch t.tags[key].(type) {
+	case bool:
+		return TagBool
+	case int32:
+		return TagInt
+	case float32:
+		return TagFloat
+	case string:
+		return TagString
+	case *TagCompound:
+		return TagCompoundType
+	case []*TagCompound:
+		return TagList
+	}
+	return 0
+}
+
+// SetBool устанавливает логическое значение
+func (t *TagCompound) SetBool(key string, value bool) {
+	t.tags[key] = value
+}
+
+// SetInt устанавливает целочисленное значение
+func (t *TagCompound) SetInt(key string, value int32) {
+	t.tags[key] = value
+}
+
+// SetFloat устанавливает вещественное значение
+func (t *TagCompound) SetFloat(key string, value float32) {
+	t.tags[key] = value
+}
+
+// SetString устанавливает строковое значение
+func (t *TagCompound) SetString(key string, value string) {
+	t.tags[key] = value
+}
+
+// SetCompound устанавливает вложенный контейнер
+func (t *TagCompound) SetCompound(key string, value *TagCompound) error {
+	if value == nil {
+		return fmt.Errorf("Пустой контейнер для ключа %q", key)
+	}
+	t.tags[key] = value
+	return nil
+}
+
+// SetList устанавливает список вложенных контейнеров (например, содержимое сундука)
+func (t *TagCompound) SetList(key string, value []*TagCompound) error {
+	for i, item := range value {
+		if item == nil {
+			return fmt.Errorf("Пустой элемент %d списка %q", i, key)
+		}
+	}
+	t.tags[key] = value
+	return nil
+}
+
+// GetBool возвращает логическое значение и признак его наличия
+func (t *TagCompound) GetBool(key string) (bool, bool) {
+	v, ok := t.tags[key].(bool)
+	return v, ok
+}
+
+// GetInt возвращает целочисленное значение и признак его наличия
+func (t *TagCompound) GetInt(key string) (int32, bool) {
+	v, ok := t.tags[key].(int32)
+	return v, ok
+}
+
+// GetFloat возвращает вещественное значение и признак его наличия
+func (t *TagCompound) GetFloat(key string) (float32, bool) {
+	v, ok := t.tags[key].(float32)
+	return v, ok
+}
+
+// GetString возвращает строковое значение и признак его наличия
+func (t *TagCompound) GetString(key string) (string, bool) {
+	v, ok := t.tags[key].(string)
+	return v, ok
+}
+
+// GetCompound возвращает вложенный контейнер или nil
+func (t *TagCompound) GetCompound(key string) *TagCompound {
+	v, _ := t.tags[key].(*TagCompound)
+	return v
+}
+
+// GetList возвращает список вложенных контейнеров или nil
+func (t *TagCompound) GetList(key string) []*TagCompound {
+	v, _ := t.tags[key].([]*TagCompound)
+	return v
+}
+
+// Clone создает глубокую копию контейнера
+func (t *TagCompound) Clone() *TagCompound {
+	c := NewTagCompound()
+	for key, value := range t.tags {
+		switch v := value.(type) {
+		case *TagCompound:
+			c.tags[key] = v.Clone()
+		case []*TagCompound:
+			list := make([]*TagCompound, len(v))
+			for i, item := range v {
+				list[i] = item.Clone()
+			}
+			c.tags[key] = list
+		default:
+			c.tags[key] = v
+		}
+	}
+	return c
+}
+
+// WriteTo записывает контейнер в двоичном виде.
+// Ключи записываются в отсортированном порядке, чтобы результат был детерминированным.
+func (t *TagCompound) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := t.write(cw, 1)
+	return cw.n, err
+}
+
+// write записывает контейнер на заданной глубине вложенности
+func (t *TagCompound) write(w io.Writer, depth int) error {
+	if depth > MaxTagDepth {
+		return fmt.Errorf("Вложенность тегов больше %d", MaxTagDepth)
+	}
+	if err := writeUint32(w, uint32(len(t.tags))); err != nil {
+		return err
+	}
+
+	for _, key := range t.Keys() {
+		tagType := t.Type(key)
+		if err := writeUint8(w, uint8(tagType)); err != nil {
+			return err
+		}
+		if err := writeString(w, key); err != nil {
+			return err
+		}
+
+		var err error
+		switch v := t.tags[key].(type) {
+		case bool:
+			var b uint8
+			if v {
+				b = 1
+			}
+			err = writeUint8(w, b)
+		case int32:
+			err = writeUint32(w, uint32(v))
+		case float32:
+			err = writeUint32(w, math.Float32bits(v))
+		case string:
+			err = writeString(w, v)
+		case *TagCompound:
+			err = v.write(w, depth+1)
+		case []*TagCompound:
+			err = writeUint32(w, uint32(len(v)))
+			for _, item := range v {
+				if err != nil {
+					break
+				}
+				err = item.write(w, depth+1)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadTagCompound читает контейнер, записанный методом WriteTo
+func ReadTagCompound(r io.Reader) (*TagCompound, error) {
+	return readTagCompound(r, 1)
+}
+
+// readTagCompound читает контейнер на заданной глубине вложенности
+func readTagCompound(r io.Reader, depth int) (*TagCompound, error) {
+	if depth > MaxTagDepth {
+		return nil, fmt.Errorf("Вложенность тегов больше %d", MaxTagDepth)
+	}
+	count, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+
+	t := NewTagCompound()
+	for i := uint32(0); i < count; i++ {
+		tagType, err := readUint8(r)
+		if err != nil {
+			return nil, err
+		}
+		key, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch TagType(tagType) {
+		case TagBool:
+			b, err := readUint8(r)
+			if err != nil {
+				return nil, err
+			}
+			t.tags[key] = b != 0
+		case TagInt:
+			v, err := readUint32(r)
+			if err != nil {
+				return nil, err
+			}
+			t.tags[key] = int32(v)
+		case TagFloat:
+			v, err := readUint32(r)
+			if err != nil {
+				return nil, err
+			}
+			t.tags[key] = math.Float32frombits(v)
+		case TagString:
+			v, err := readString(r)
+			if err != nil {
+				return nil, err
+			}
+			t.tags[key] = v
+		case TagCompoundType:
+			v, err := readTagCompound(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t.tags[key] = v
+		case TagList:
+			n, err := readUint32(r)
+			if err != nil {
+				return nil, err
+			}
+			// Длина списка взята из входных данных, поэтому список растет по мере чтения элементов
+			list := []*TagCompound{}
+			for j := uint32(0); j < n; j++ {
+				item, err := readTagCompound(r, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			t.tags[key] = list
+		default:
+			return nil, fmt.Errorf("Неизвестный тип тега %d для ключа %q", tagType, key)
+		}
+	}
+
+	return t, nil
+}
+
+// countingWriter подсчитывает количество записанных байт
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeUint8 записывает один байт
+func writeUint8(w io.Writer, v uint8) error {
+	_, err := w.Write([]byte{v})
+	return err
+}
+
+// writeUint16 записывает 16-битное число
+func writeUint16(w io.Writer, v uint16) error {
+	return binary.Write(w, binary.LittleEndian, v)
+}
+
+// writeUint32 записывает 32-битное число
+func writeUint32(w io.Writer, v uint32) error {
+	return binary.Write(w, binary.LittleEndian, v)
+}
+
+// writeString записывает строку с префиксом длины
+func writeString(w io.Writer, s string) error {
+	if len(s) > math.MaxUint16 {
+		return fmt.Errorf("Строка слишком длинная: %d байт", len(s))
+	}
+	if err := writeUint16(w, uint16(len(s))); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// readUint8 читает один байт
+func readUint8(r io.Reader) (uint8, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(r, buf[:])
+	return buf[0], err
+}
+
+// readUint16 читает 16-битное число
+func readUint16(r io.Reader) (uint16, error) {
+	var v uint16
+	err := binary.Read(r, binary.LittleEndian, &v)
+	return v, err
+}
+
+// readUint32 читает 32-битное число
+func readUint32(r io.Reader) (uint32, error) {
+	var v uint32
+	err := binary.Read(r, binary.LittleEndian, &v)
+	return v, err
+}
+
+// readString читает строку с префиксом длины
+func readString(r io.Reader) (string, error) {
+	n, err := readUint16(r)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -11,6 +11,13 @@ import (
 type World struct {
 	chunks      map[string]*Chunk
 	chunksMutex sync.RWMutex
+
+	// OnBlockFall вызывается, когда падающий блок лишился опоры и был удален из мира.
+	// Если обработчик не задан, падающие блоки остаются на месте.
+	OnBlockFall func(pos BlockPos, state BlockState)
+
+	// OnBlockChanged вызывается после изменения блока (например, чтобы разбудить тела рядом с ним)
+	OnBlockChanged func(pos BlockPos)
 }
 
 // NewWorld создает новый мир
@@ -54,30 +61,72 @@ func (w *World) GetBlock(pos mgl32.Vec3) *BlockData {
 	return chunk.GetBlockFromWorldPos(pos)
 }
 
-// SetBlock устанавливает блок по мировым координатам
-func (w *World) SetBlock(pos mgl32.Vec3, blockType string, active bool) {
+// GetBlockEntity возвращает блок-сущность по мировым координатам
+func (w *World) GetBlockEntity(pos mgl32.Vec3) *BlockEntity {
 	chunk := w.GetChunk(pos)
 	if chunk == nil {
+		return nil
+	}
+
+	return chunk.GetBlockEntityFromWorldPos(pos)
+}
+
+// SetBlock устанавливает состояние блока по мировым координатам.
+// Состояние воздуха удаляет блок.
+func (w *World) SetBlock(pos mgl32.Vec3, state BlockState) error {
+	id, err := DefaultRegistry.StateID(state)
+	if err != nil {
+		return err
+	}
+
+	w.setBlockAt(floorInt(pos.X()), floorInt(pos.Y()), floorInt(pos.Z()), id)
+	return nil
+}
+
+// setBlockAt устанавливает состояние блока по целочисленным мировым координатам
+// и оповещает соседние блоки об изменении
+func (w *World) setBlockAt(x, y, z int, id StateID) {
+	chunk, localX, localZ := w.chunkAt(x, z)
+	if chunk == nil {
+		if id == AirStateID {
+			return
+		}
 		// Если чанк не существует, создаем его
-		chunkPos := PositionToChunkCoords(pos)
-		chunk = NewChunk(chunkPos)
+		chunk = NewChunk(mgl32.Vec3{float32(x - localX), 0, float32(z - localZ)})
 		w.AddChunk(chunk)
 	}
 
-	// Вычисляем локальные координаты блока внутри чанка
-	localX := int(pos.X()) % ChunkWidth
-	localY := int(pos.Y())
-	localZ := int(pos.Z()) % ChunkWidth
+	chunk.SetBlockState(localX, y, localZ, id)
+	w.neighbourChanged(x, y, z)
+}
 
-	// Обрабатываем отрицательные координаты
-	if localX < 0 {
-		localX += ChunkWidth
+// neighbourChanged оповещает блок и его соседей об изменении блока в заданной ячейке
+func (w *World) neighbourChanged(x, y, z int) {
+	if w.OnBlockChanged != nil {
+		w.OnBlockChanged(BlockPos{x, y, z})
+	}
+	w.checkFall(x, y, z)
+	for _, offset := range neighbourOffsets {
+		w.checkFall(x+offset[0], y+offset[1], z+offset[2])
 	}
-	if localZ < 0 {
-		localZ += ChunkWidth
+}
+
+// Смещения шести соседних ячеек
+var neighbourOffsets = [6][3]int{
+	{0, -1, 0}, {0, 1, 0},
+	{0, 0, -1}, {0, 0, 1},
+	{-1, 0, 0}, {1, 0, 0},
+}
+
+// GetBlockState возвращает состояние блока по мировым координатам
+func (w *World) GetBlockState(pos mgl32.Vec3) BlockState {
+	block := w.GetBlock(pos)
+	if block == nil || !block.Active {
+		return BlockState{}
 	}
 
-	chunk.SetBlock(localX, localY, localZ, blockType, active)
+	state, _ := DefaultRegistry.State(block.State)
+	return state
 }
 
 // GetAllChunks возвращает все чанки мира
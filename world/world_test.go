@@ -0,0 +1,463 @@
+package world
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/user/gengine/physics"
+)
+
+// floorWorld создает мир с каменным полом в слое y = 0 и мостом падающих блоков
+func floorWorld(t *testing.T) (*World, *physics.PhysicsEngine, *FallingBlocks) {
+	w := NewWorld()
+	chunk := NewChunk(mgl32.Vec3{0, 0, 0})
+	for x := 0; x < ChunkWidth; x++ {
+		for z := 0; z < ChunkWidth; z++ {
+			if err := chunk.SetBlock(x, 0, z, "stone", true); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	w.AddChunk(chunk)
+
+	engine := physics.NewPhysicsEngine(w)
+	return w, engine, NewFallingBlocks(w, engine)
+}
+
+func TestFallingBlockLanding(t *testing.T) {
+	tests := []struct {
+		name string
+		// Блок в ячейке (4, 1, 4), на который падает песок
+		below string
+		// Ожидаемый блок в ячейке приземления и уроненный ли песок
+		landedAt mgl32.Vec3
+		want     string
+		dropped  bool
+	}{
+		{"на пол", "", mgl32.Vec3{4, 1, 4}, "sand", false},
+		{"сквозь траву", "tall_grass", mgl32.Vec3{4, 1, 4}, "sand", false},
+		{"на плиту", "stone_slab", mgl32.Vec3{4, 2, 4}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, engine, falling := floorWorld(t)
+			if tt.below != "" {
+				if err := w.SetBlock(mgl32.Vec3{4, 1, 4}, NewBlockState(tt.below)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			landed, dropped := false, false
+			falling.OnLanded = func(block *FallingBlock, pos BlockPos) { landed = true }
+			falling.OnDropped = func(block *FallingBlock, position mgl32.Vec3) { dropped = true }
+
+			// Песок в воздухе сразу превращается в падающее тело
+			if err := w.SetBlock(mgl32.Vec3{4, 6, 4}, NewBlockState("sand")); err != nil {
+				t.Fatal(err)
+			}
+			if len(falling.Blocks()) != 1 || w.GetBlockState(mgl32.Vec3{4, 6, 4}).Block != "" {
+				t.Fatalf("песок без опоры не начал падать")
+			}
+
+			for i := 0; i < 300 && len(falling.Blocks()) > 0; i++ {
+				engine.Tick(1.0 / 60)
+			}
+			if len(falling.Blocks()) != 0 || len(engine.Bodies()) != 0 {
+				t.Fatalf("блок не приземлился: тел в движке %d", len(engine.Bodies()))
+			}
+			if landed == tt.dropped || dropped != tt.dropped {
+				t.Errorf("OnLanded = %v, OnDropped = %v, ожидалось уронить: %v", landed, dropped, tt.dropped)
+			}
+			if got := w.GetBlockState(tt.landedAt).Block; got != tt.want {
+				t.Errorf("в ячейке %v блок %q, ожидался %q", tt.landedAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallingColumn(t *testing.T) {
+	w, engine, falling := floorWorld(t)
+
+	// Столб песка на опоре из камня: после удаления опоры падает целиком и складывается обратно
+	for y := 3; y <= 5; y++ {
+		if err := w.SetBlock(mgl32.Vec3{4, float32(y), 4}, NewBlockState("stone")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for y := 6; y <= 8; y++ {
+		if err := w.SetBlock(mgl32.Vec3{4, float32(y), 4}, NewBlockState("sand")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for y := 3; y <= 5; y++ {
+		if err := w.SetBlock(mgl32.Vec3{4, float32(y), 4}, BlockState{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(falling.Blocks()) != 3 {
+		t.Fatalf("падает %d блоков, ожидалось 3", len(falling.Blocks()))
+	}
+
+	for i := 0; i < 600 && len(falling.Blocks()) > 0; i++ {
+		engine.Tick(1.0 / 60)
+	}
+	if len(falling.Blocks()) != 0 {
+		t.Fatalf("не приземлилось %d блоков", len(falling.Blocks()))
+	}
+	for y := 1; y <= 3; y++ {
+		if got := w.GetBlockState(mgl32.Vec3{4, float32(y), 4}).Block; got != "sand" {
+			t.Errorf("в ячейке y = %d блок %q, ожидался песок", y, got)
+		}
+	}
+	if got := w.GetBlockState(mgl32.Vec3{4, 4, 4}).Block; got != "" {
+		t.Errorf("над столбом остался блок %q", got)
+	}
+}
+
+func TestRaycast(t *testing.T) {
+	w, _, _ := floorWorld(t)
+	if err := w.SetBlock(mgl32.Vec3{8, 1, 4}, NewBlockState("oak_fence")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetBlock(mgl32.Vec3{10, 1, 4}, NewBlockState("stone_slab")); err != nil {
+		t.Fatal(err)
+	}
+	inf := float32(math.Inf(1))
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name      string
+		origin    mgl32.Vec3
+		direction mgl32.Vec3
+		max       float32
+		hit       bool
+		block     BlockPos
+		distance  float32
+		normal    mgl32.Vec3
+	}{
+		{"в пол", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{0, -1, 0}, 10, true, BlockPos{4, 0, 4}, 4, mgl32.Vec3{0, 1, 0}},
+		{"не дотягивается до пола", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{0, -1, 0}, 3, false, BlockPos{}, 0, mgl32.Vec3{}},
+		// Забор выше блока: луч попадает в него из ячейки над ним
+		{"в верх забора", mgl32.Vec3{8.5, 5, 4.5}, mgl32.Vec3{0, -1, 0}, 10, true, BlockPos{8, 1, 4}, 2.5, mgl32.Vec3{0, 1, 0}},
+		{"в плиту", mgl32.Vec3{10.5, 5, 4.5}, mgl32.Vec3{0, -1, 0}, 10, true, BlockPos{10, 1, 4}, 3.5, mgl32.Vec3{0, 1, 0}},
+		{"в бок забора", mgl32.Vec3{4.5, 1.5, 4.5}, mgl32.Vec3{1, 0, 0}, 10, true, BlockPos{8, 1, 4}, 3.875, mgl32.Vec3{-1, 0, 0}},
+		// Лучи, уходящие из мира, заканчиваются даже при бесконечной дальности
+		{"в небо бесконечно", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{0, 1, 0}, inf, false, BlockPos{}, 0, mgl32.Vec3{}},
+		{"вдоль мира бесконечно", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{1, 0, 1}, inf, false, BlockPos{}, 0, mgl32.Vec3{}},
+		{"нечисловая дальность", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{0, -1, 0}, nan, false, BlockPos{}, 0, mgl32.Vec3{}},
+		{"отрицательная дальность", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{0, -1, 0}, -1, false, BlockPos{}, 0, mgl32.Vec3{}},
+		{"нулевое направление", mgl32.Vec3{4.5, 5, 4.5}, mgl32.Vec3{}, 10, false, BlockPos{}, 0, mgl32.Vec3{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hit, ok := w.Raycast(tt.origin, tt.direction, tt.max)
+			if ok != tt.hit {
+				t.Fatalf("попадание = %v (%+v), ожидалось %v", ok, hit, tt.hit)
+			}
+			if !ok {
+				return
+			}
+			if hit.Block != tt.block {
+				t.Errorf("Block = %v, ожидалось %v", hit.Block, tt.block)
+			}
+			if math.Abs(float64(hit.Distance-tt.distance)) > 1e-4 {
+				t.Errorf("Distance = %v, ожидалось %v", hit.Distance, tt.distance)
+			}
+			if hit.Normal != tt.normal {
+				t.Errorf("Normal = %v, ожидалось %v", hit.Normal, tt.normal)
+			}
+		})
+	}
+}
+
+func TestBlockStateShapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		state BlockState
+		want  []physics.Box
+	}{
+		{"нижняя плита", NewBlockState("stone_slab"), []physics.Box{shapeBox(0, 0, 0, 1, 0.5, 1)}},
+		{"верхняя плита", NewBlockState("stone_slab").With("type", "top"), []physics.Box{shapeBox(0, 0.5, 0, 1, 1, 1)}},
+		{"двойная плита", NewBlockState("stone_slab").With("type", "double"), []physics.Box{shapeBox(0, 0, 0, 1, 1, 1)}},
+		{"ступени на север", NewBlockState("oak_stairs"), []physics.Box{
+			shapeBox(0, 0, 0, 1, 0.5, 1), shapeBox(0, 0.5, 0, 1, 1, 0.5),
+		}},
+		{"ступени на восток", NewBlockState("oak_stairs").With("facing", "east"), []physics.Box{
+			shapeBox(0, 0, 0, 1, 0.5, 1), shapeBox(0.5, 0.5, 0, 1, 1, 1),
+		}},
+		{"перевернутые ступени", NewBlockState("oak_stairs").With("facing", "south").With("half", "top"), []physics.Box{
+			shapeBox(0, 0.5, 0, 1, 1, 1), shapeBox(0, 0, 0.5, 1, 0.5, 1),
+		}},
+		{"забор", NewBlockState("oak_fence"), []physics.Box{shapeBox(fenceMin, 0, fenceMin, fenceMax, fenceHeight, fenceMax)}},
+		{"закрытая дверь", NewBlockState("oak_door"), []physics.Box{shapeBox(0, 0, 1-doorThickness, 1, 1, 1)}},
+		// Открытая дверь на север поворачивается к востоку
+		{"открытая дверь", NewBlockState("oak_door").With("open", "true"), []physics.Box{shapeBox(0, 0, 0, doorThickness, 1, 1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := DefaultRegistry.StateID(tt.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := DefaultRegistry.CollisionShapes(id)
+			if len(got) != len(tt.want) {
+				t.Fatalf("формы %v, ожидалось %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("форма %d = %v, ожидалось %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// chestItems создает теги содержимого сундука
+func chestItems(t *testing.T) *TagCompound {
+	tags := NewTagCompound()
+	tags.SetBool("locked", true)
+	tags.SetInt("slots", 27)
+	tags.SetFloat("weight", 2.5)
+	tags.SetString("name", "Сундук")
+	owner := NewTagCompound()
+	owner.SetString("player", "steve")
+	if err := tags.SetCompound("owner", owner); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []*TagCompound
+	for i := int32(0); i < 3; i++ {
+		item := NewTagCompound()
+		item.SetString("id", "stone")
+		item.SetInt("count", i+1)
+		items = append(items, item)
+	}
+	if err := tags.SetList("items", items); err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.SetList("empty", nil); err != nil {
+		t.Fatal(err)
+	}
+	return tags
+}
+
+func TestTagRoundTrip(t *testing.T) {
+	tags := chestItems(t)
+	var buf bytes.Buffer
+	if _, err := tags.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() = %v", err)
+	}
+	data := buf.Bytes()
+
+	read, err := ReadTagCompound(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadTagCompound() = %v", err)
+	}
+	var again bytes.Buffer
+	if _, err := read.WriteTo(&again); err != nil {
+		t.Fatalf("WriteTo() = %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), data) {
+		t.Fatalf("прочитанные теги записываются иначе")
+	}
+	if v, _ := read.GetFloat("weight"); v != 2.5 {
+		t.Errorf("weight = %v, ожидалось 2.5", v)
+	}
+	if items := read.GetList("items"); len(items) != 3 {
+		t.Errorf("items = %v, ожидалось 3 элемента", items)
+	}
+	if read.Type("empty") != TagList {
+		t.Errorf("пустой список прочитан как %v", read.Type("empty"))
+	}
+
+	// Копия не разделяет вложенные контейнеры с оригиналом
+	clone := read.Clone()
+	clone.GetCompound("owner").SetString("player", "alex")
+	clone.GetList("items")[0].SetInt("count", 64)
+	if v, _ := read.GetCompound("owner").GetString("player"); v != "steve" {
+		t.Errorf("изменение копии затронуло оригинал: player = %q", v)
+	}
+	if v, _ := read.GetList("items")[0].GetInt("count"); v != 1 {
+		t.Errorf("изменение копии затронуло оригинал: count = %d", v)
+	}
+}
+
+// nestedTags возвращает запись контейнеров, вложенных друг в друга depth раз
+func nestedTags(depth int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < depth; i++ {
+		writeUint32(&buf, 1)
+		writeUint8(&buf, uint8(TagCompoundType))
+		writeString(&buf, "inner")
+	}
+	writeUint32(&buf, 0)
+	return buf.Bytes()
+}
+
+func TestReadTagErrors(t *testing.T) {
+	// Список из миллиарда элементов без данных
+	var hugeList bytes.Buffer
+	writeUint32(&hugeList, 1)
+	writeUint8(&hugeList, uint8(TagList))
+	writeString(&hugeList, "items")
+	writeUint32(&hugeList, 1<<30)
+
+	tests := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{"допустимая вложенность", nestedTags(MaxTagDepth - 1), true},
+		{"слишком глубокая вложенность", nestedTags(MaxTagDepth), false},
+		{"длина списка больше данных", hugeList.Bytes(), false},
+		{"неизвестный тип", []byte{1, 0, 0, 0, 99, 1, 0, 'k'}, false},
+		{"обрезанная запись", []byte{2, 0, 0, 0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadTagCompound(bytes.NewReader(tt.data))
+			if (err == nil) != tt.ok {
+				t.Errorf("ReadTagCompound() = %v, ожидался успех: %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTagNilValues(t *testing.T) {
+	tags := NewTagCompound()
+	if err := tags.SetList("items", []*TagCompound{NewTagCompound(), nil}); err == nil {
+		t.Errorf("SetList() с пустым элементом = nil, ожидалась ошибка")
+	}
+	if err := tags.SetCompound("owner", nil); err == nil {
+		t.Errorf("SetCompound() с пустым контейнером = nil, ожидалась ошибка")
+	}
+	if tags.Len() != 0 {
+		t.Errorf("отклоненные значения сохранены: %v", tags.Keys())
+	}
+
+	// Контейнер, вложенный сам в себя, не записывается бесконечно
+	if err := tags.SetCompound("self", tags); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tags.WriteTo(io.Discard); err == nil {
+		t.Errorf("WriteTo() для вложенного в себя контейнера = nil, ожидалась ошибка")
+	}
+}
+
+func TestChunkRoundTrip(t *testing.T) {
+	RegisterBlockEntityType("chest", &BlockEntityType{Name: "chest"})
+	chunk := NewChunk(mgl32.Vec3{-16, 0, 32})
+	for x := 0; x < ChunkWidth; x++ {
+		if err := chunk.SetBlock(x, 0, 3, "stone", true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stairs, err := DefaultRegistry.StateID(NewBlockState("oak_stairs").With("facing", "east").With("half", "top"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk.SetBlockState(2, 1, 3, stairs)
+	if err := chunk.SetBlock(5, 255, 7, "chest", true); err != nil {
+		t.Fatal(err)
+	}
+	chunk.GetBlockEntity(5, 255, 7).Data = chestItems(t)
+
+	var buf bytes.Buffer
+	if err := chunk.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize() = %v", err)
+	}
+	data := buf.Bytes()
+	read, err := DeserializeChunk(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DeserializeChunk() = %v", err)
+	}
+
+	if read.Position != chunk.Position {
+		t.Errorf("Position = %v, ожидалось %v", read.Position, chunk.Position)
+	}
+	if got := read.GetBlockState(2, 1, 3).String(); got != "oak_stairs[facing=east,half=top,waterlogged=false]" {
+		t.Errorf("ступени прочитаны как %s", got)
+	}
+	entity := read.GetBlockEntity(5, 255, 7)
+	if entity == nil || entity.Type != "chest" {
+		t.Fatalf("сущность сундука = %+v", entity)
+	}
+	if v, _ := entity.Data.GetList("items")[2].GetInt("count"); v != 3 {
+		t.Errorf("count третьего предмета = %d, ожидалось 3", v)
+	}
+
+	var again bytes.Buffer
+	if err := read.Serialize(&again); err != nil {
+		t.Fatalf("Serialize() = %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), data) {
+		t.Errorf("прочитанный чанк записывается иначе")
+	}
+}
+
+func TestPaletteRoundTrip(t *testing.T) {
+	chunk := NewChunk(mgl32.Vec3{0, 0, 0})
+	var states []BlockState
+	for _, facing := range []string{"north", "south", "west", "east"} {
+		for _, half := range []string{"bottom", "top"} {
+			states = append(states, NewBlockState("oak_stairs").With("facing", facing).With("half", half))
+		}
+		states = append(states, NewBlockState("oak_door").With("facing", facing).With("open", "true"))
+	}
+	for _, slab := range []string{"bottom", "top", "double"} {
+		states = append(states, NewBlockState("stone_slab").With("type", slab).With("waterlogged", "true"))
+	}
+	for i, state := range states {
+		id, err := DefaultRegistry.StateID(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk.SetBlockState(i%ChunkWidth, 1+i/ChunkWidth, 0, id)
+	}
+
+	var buf bytes.Buffer
+	if err := chunk.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize() = %v", err)
+	}
+	read, err := DeserializeChunk(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("DeserializeChunk() = %v", err)
+	}
+	for i := range states {
+		x, y := i%ChunkWidth, 1+i/ChunkWidth
+		if got, want := read.GetBlockState(x, y, 0).String(), chunk.GetBlockState(x, y, 0).String(); got != want {
+			t.Errorf("блок %d прочитан как %s, ожидалось %s", i, got, want)
+		}
+	}
+}
+
+func TestPaletteUnknownBlock(t *testing.T) {
+	chunk := NewChunk(mgl32.Vec3{0, 0, 0})
+	if err := chunk.SetBlock(0, 0, 0, "stone", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := chunk.SetBlock(0, 0, 0, "unknown", true); err == nil {
+		t.Errorf("SetBlock() с неизвестным блоком = nil, ожидалась ошибка")
+	}
+
+	var buf bytes.Buffer
+	if err := chunk.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize() = %v", err)
+	}
+
+	// Файл, сохраненный с блоком, которого нет в реестре, не загружается и не меняет реестр
+	data := bytes.Replace(buf.Bytes(), []byte("stone"), []byte("stonx"), 1)
+	if _, err := DeserializeChunk(bytes.NewReader(data)); err == nil {
+		t.Errorf("DeserializeChunk() с неизвестным блоком = nil, ожидалась ошибка")
+	}
+	if def := DefaultRegistry.Get("stonx"); def != nil {
+		t.Errorf("неизвестный блок зарегистрирован при загрузке: %+v", def)
+	}
+}